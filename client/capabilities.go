@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/GridlessCompute/wmapi/transport"
+)
+
+// Capabilities describes the firmware of a miner and which registered commands it supports.
+type Capabilities struct {
+	APIVersion      string
+	FirmwareVersion string
+	Platform        string
+	Chip            string
+
+	// supported holds every registered command with whether the firmware supports it.
+	supported map[string]bool
+}
+
+// ProbeCapabilities reads get_version once and derives the capabilities with NewCapabilities.
+func ProbeCapabilities(ctx context.Context, r *ReadAPI) (*Capabilities, error) {
+	version, err := r.VersionContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe capabilities: %w", err)
+	}
+	return NewCapabilities(r.protocol(), &version.Msg), nil
+}
+
+// NewCapabilities derives the commands supported by the firmware that sent version from the
+// minimum API version in the command registry and from what api can express. A nil version
+// yields capabilities that know nothing about the firmware and support every command.
+func NewCapabilities(api transport.Protocol, version *VersionInfo) *Capabilities {
+	if version == nil {
+		return &Capabilities{}
+	}
+	c := &Capabilities{
+		APIVersion:      version.APIVer,
+		FirmwareVersion: version.FwVer,
+		Platform:        version.Platform,
+		Chip:            version.Chip,
+		supported:       make(map[string]bool, len(commands)),
+	}
+	for name, cmd := range commands {
+		c.supported[name] = apiVersionAtLeast(c.APIVersion, cmd.MinAPIVersion) && protocolSupports(api, cmd)
+	}
+	return c
+}
+
+// protocolSupports reports whether api can send cmd at all.
+func protocolSupports(api transport.Protocol, cmd Command) bool {
+	switch cmd.Name {
+	case CmdUpdateFirmware:
+		_, ok := api.(transport.Uploader)
+		return ok
+	case CmdDownloadLogs:
+		_, ok := api.(transport.Downloader)
+		return ok
+	}
+	if s, ok := api.(transport.CommandSupporter); ok {
+		return s.Supports(cmd.Name, cmd.Kind == CommandWrite)
+	}
+	return true
+}
+
+// Supports reports whether the firmware supports cmd. Commands missing from the registry are
+// assumed to be supported, as is everything when c is nil.
+func (c *Capabilities) Supports(cmd string) bool {
+	if c == nil {
+		return true
+	}
+	supported, known := c.supported[cmd]
+	return supported || !known
+}
+
+// Supported returns the registered commands the firmware supports, sorted by name.
+func (c *Capabilities) Supported() []string {
+	if c == nil {
+		return nil
+	}
+	var names []string
+	for name, ok := range c.supported {
+		if ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// check returns an error wrapping transport.ErrUnsupported if the firmware does not support cmd.
+func (c *Capabilities) check(cmd string) error {
+	if c.Supports(cmd) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s on api_ver %s", transport.ErrUnsupported, cmd, c.APIVersion)
+}
+
+// apiVersionAtLeast compares dotted version numbers such as "2.0.5". Missing trailing parts count
+// as zero, so "2.0" equals "2.0.0". A version that cannot be parsed is assumed to be recent
+// enough, so unusual firmware is not locked out.
+func apiVersionAtLeast(version, minimum string) bool {
+	v, ok := parseAPIVersion(version)
+	if !ok {
+		return true
+	}
+	m, _ := parseAPIVersion(minimum)
+	n := max(len(v), len(m))
+	v = append(v, make([]int, n-len(v))...)
+	m = append(m, make([]int, n-len(m))...)
+	return slices.Compare(v, m) >= 0
+}
+
+func parseAPIVersion(version string) ([]int, bool) {
+	version = strings.TrimLeft(strings.TrimSpace(version), "vV")
+	if version == "" {
+		return nil, false
+	}
+	parts := strings.Split(version, ".")
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
@@ -0,0 +1,26 @@
+package client
+
+import "testing"
+
+func TestAPIVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version, minimum string
+		want             bool
+	}{
+		{"2.0.5", "2.0.0", true},
+		{"2.0.5", "2.0.5", true},
+		{"2.0.4", "2.0.5", false},
+		{"2.0", "2.0.0", true},
+		{"2.0.0", "2.0", true},
+		{"2.1", "2.0.5", true},
+		{"2", "2.0.1", false},
+		{"v3.0.1", "2.0.5", true},
+		{"", "2.0.5", true},
+		{"unknown", "2.0.5", true},
+	}
+	for _, tt := range tests {
+		if got := apiVersionAtLeast(tt.version, tt.minimum); got != tt.want {
+			t.Errorf("apiVersionAtLeast(%q, %q) = %v, want %v", tt.version, tt.minimum, got, tt.want)
+		}
+	}
+}
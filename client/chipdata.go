@@ -0,0 +1,143 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChipBatch is one group of the Chip Data string of edevs, such as "K88Z315-2230 BINV01-195B". The
+// firmware does not report chips individually; it names the wafer lot, date code and bin of the
+// chips on the board, with one group per batch if a board carries chips of several.
+type ChipBatch struct {
+	// Lot is the wafer lot, e.g. "K88Z315".
+	Lot string `json:"lot"`
+	// Year and Week are decoded from the date code, e.g. 2022 and 30 for "2230".
+	Year int `json:"year"`
+	Week int `json:"week"`
+	// Bin is the speed bin the chips were sorted into, e.g. "V01", and Grade the firmware's suffix
+	// to it, e.g. "195B". Both are empty if the group has no BIN part.
+	Bin   string `json:"bin,omitempty"`
+	Grade string `json:"grade,omitempty"`
+}
+
+// ParseChipData decodes a Chip Data string. An empty string yields no batches.
+func ParseChipData(s string) ([]ChipBatch, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' || r == ';' })
+
+	var batches []ChipBatch
+	for _, f := range fields {
+		if bin, ok := strings.CutPrefix(f, "BIN"); ok {
+			if len(batches) == 0 || batches[len(batches)-1].Bin != "" {
+				return nil, fmt.Errorf("invalid chip data %q: %s does not follow a lot", s, f)
+			}
+			b := &batches[len(batches)-1]
+			b.Bin, b.Grade, _ = strings.Cut(bin, "-")
+			continue
+		}
+
+		lot, date, ok := strings.Cut(f, "-")
+		if !ok || lot == "" || len(date) != 4 {
+			return nil, fmt.Errorf("invalid chip data %q: malformed lot %s", s, f)
+		}
+		yy, err1 := strconv.Atoi(date[:2])
+		week, err2 := strconv.Atoi(date[2:])
+		if err1 != nil || err2 != nil || week < 1 || week > 53 {
+			return nil, fmt.Errorf("invalid chip data %q: malformed date code %s", s, date)
+		}
+		batches = append(batches, ChipBatch{Lot: lot, Year: 2000 + yy, Week: week})
+	}
+	return batches, nil
+}
+
+// ChipBatches parses b.ChipData.
+func (b *Hashboard) ChipBatches() ([]ChipBatch, error) {
+	return ParseChipData(b.ChipData)
+}
+
+// ChipLimits are the thresholds of AssessChips.
+type ChipLimits struct {
+	// ExpectedChips is the number of chips per board. ExpectedChipsByModel overrides it for the
+	// boards whose Model it lists; boards from Telemetry lack the Model, so use ReadAPI.Hashboards
+	// for a fleet of mixed models. Boards whose expected count is zero are not checked for dead
+	// chips.
+	ExpectedChips        int
+	ExpectedChipsByModel map[string]int
+	// FailedDeadChips is the fraction of dead chips at which a board has failed; any dead chip
+	// degrades it.
+	FailedDeadChips float64
+	// DegradedVolDiff is the chip voltage spread in mV at which a board is degraded.
+	DegradedVolDiff Float
+	// DegradedTempSpread is the difference between the hottest and coolest chip at which a board
+	// is degraded, a sign of chips that run without hashing or of poor cooling.
+	DegradedTempSpread Celsius
+}
+
+// DefaultChipLimits are reasonable limits for AssessChips. The chip count differs between
+// models, so they leave dead chips unchecked; set ExpectedChips or ExpectedChipsByModel to count
+// them.
+var DefaultChipLimits = ChipLimits{
+	FailedDeadChips:    0.1,
+	DegradedVolDiff:    10,
+	DegradedTempSpread: 25,
+}
+
+// ChipHealth is the chip-level assessment of one board.
+type ChipHealth struct {
+	Slot    int         `json:"slot"`
+	Batches []ChipBatch `json:"batches,omitempty"`
+	// ExpectedChips is zero if it could not be determined.
+	ExpectedChips int         `json:"expected_chips"`
+	DeadChips     int         `json:"dead_chips"`
+	Status        BoardStatus `json:"status"`
+	Reasons       []string    `json:"reasons,omitempty"`
+}
+
+// AssessChips flags boards with dead or degraded chips. Dead chips are the difference between the
+// expected and the effective chip count; a board with an expected count is degraded if its
+// effective count is unknown. Degraded chips show as a wide spread of chip voltages or
+// temperatures. A Chip Data string that cannot be parsed is reported but does not lower the status.
+func AssessChips(boards []Hashboard, limits ChipLimits) []ChipHealth {
+	health := make([]ChipHealth, len(boards))
+	for i, b := range boards {
+		h := &health[i]
+		h.Slot = b.Slot
+		report := func(status BoardStatus, format string, args ...any) {
+			h.Status = max(h.Status, status)
+			h.Reasons = append(h.Reasons, fmt.Sprintf(format, args...))
+		}
+
+		batches, err := b.ChipBatches()
+		if err != nil {
+			report(BoardHealthy, "%v", err)
+		}
+		h.Batches = batches
+
+		h.ExpectedChips = limits.ExpectedChips
+		if n, ok := limits.ExpectedChipsByModel[b.Model]; ok {
+			h.ExpectedChips = n
+		}
+		switch {
+		case h.ExpectedChips <= 0:
+			h.ExpectedChips = 0
+		case !b.EffectiveChips.Valid():
+			report(BoardDegraded, "effective chip count not reported")
+		case int(b.EffectiveChips) < h.ExpectedChips:
+			h.DeadChips = h.ExpectedChips - int(b.EffectiveChips)
+			status := BoardDegraded
+			if limits.FailedDeadChips > 0 && float64(h.DeadChips) >= limits.FailedDeadChips*float64(h.ExpectedChips) {
+				status = BoardFailed
+			}
+			report(status, "%d of %d chips dead", h.DeadChips, h.ExpectedChips)
+		}
+
+		if b.ChipVolDiff.Valid() && limits.DegradedVolDiff > 0 && b.ChipVolDiff >= limits.DegradedVolDiff {
+			report(BoardDegraded, "chip voltage spread %v mV reaches %v mV", b.ChipVolDiff, limits.DegradedVolDiff)
+		}
+		spread := b.ChipTempMax - b.ChipTempMin
+		if Float(spread).Valid() && limits.DegradedTempSpread > 0 && spread >= limits.DegradedTempSpread {
+			report(BoardDegraded, "chip temperatures span %v to %v, average %v", b.ChipTempMin, b.ChipTempMax, b.ChipTempAvg)
+		}
+	}
+	return health
+}
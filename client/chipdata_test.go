@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+func TestAssessChipsExpectedCount(t *testing.T) {
+	boards := []Hashboard{
+		{Slot: 0, Model: "M50S", EffectiveChips: 148, ChipData: "K88Z315-2230 BINV01-195B"},
+		{Slot: 1, Model: "M50S", EffectiveChips: 100},
+		{Slot: 2, Model: "M30S", EffectiveChips: Float(math.NaN())},
+		{Slot: 3, Model: "M60", EffectiveChips: 148},
+	}
+	limits := DefaultChipLimits
+	limits.ExpectedChipsByModel = map[string]int{"M50S": 148, "M30S": 156}
+
+	health := AssessChips(boards, limits)
+	want := []struct {
+		status  BoardStatus
+		reasons []string
+	}{
+		{BoardHealthy, nil},
+		{BoardFailed, []string{"48 of 148 chips dead"}},
+		{BoardDegraded, []string{"effective chip count not reported"}},
+		{BoardHealthy, nil},
+	}
+	for i, h := range health {
+		if h.Status != want[i].status || !slices.Equal(h.Reasons, want[i].reasons) {
+			t.Errorf("slot %d: %v %q, want %v %q", h.Slot, h.Status, h.Reasons, want[i].status, want[i].reasons)
+		}
+	}
+	if b := health[0].Batches; len(b) != 1 || b[0] != (ChipBatch{Lot: "K88Z315", Year: 2022, Week: 30, Bin: "V01", Grade: "195B"}) {
+		t.Errorf("slot 0 batches = %+v", b)
+	}
+	if h := health[3]; h.ExpectedChips != 0 || h.DeadChips != 0 {
+		t.Errorf("slot 3 = %+v, want no expected or dead chips", h)
+	}
+}
+
+// TestAssessChipsDefaultLimits checks that DefaultChipLimits, which know no chip count, find
+// nothing wrong with the boards of a healthy simulated miner.
+func TestAssessChipsDefaultLimits(t *testing.T) {
+	sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+	if err := sim.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r := &ReadAPI{API: transport.NewWhatsminerAPI(), Token: &transport.WhatsminerAccessToken{IPAddress: sim.Host(), Port: sim.Port()}}
+	boards, err := r.HashboardsContext(ctx)
+	if err != nil {
+		t.Fatalf("HashboardsContext: %v", err)
+	}
+	if len(boards) == 0 {
+		t.Fatal("HashboardsContext returned no boards")
+	}
+	for _, h := range AssessChips(boards, DefaultChipLimits) {
+		if h.Status != BoardHealthy || len(h.Reasons) != 0 {
+			t.Errorf("slot %d: %v %q, want healthy", h.Slot, h.Status, h.Reasons)
+		}
+	}
+}
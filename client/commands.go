@@ -0,0 +1,318 @@
+package client
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Names of the API 2.x commands known to this package. Methods of ReadAPI and WriteAPI refer to
+// commands only through these constants, so a misspelt name fails to compile.
+const (
+	CmdSummary      = "summary"
+	CmdPools        = "pools"
+	CmdEdevs        = "edevs"
+	CmdDevDetails   = "devdetails"
+	CmdGetPSU       = "get_psu"
+	CmdGetVersion   = "get_version"
+	CmdStatus       = "status"
+	CmdGetMinerInfo = "get_miner_info"
+	CmdGetErrorCode = "get_error_code"
+	CmdGetToken     = "get_token"
+
+	CmdUpdatePools        = "update_pools"
+	CmdRestartBTMiner     = "restart_btminer"
+	CmdPowerOff           = "power_off"
+	CmdPowerOn            = "power_on"
+	CmdSetLED             = "set_led"
+	CmdSetLowPower        = "set_low_power"
+	CmdSetNormalPower     = "set_normal_power"
+	CmdSetHighPower       = "set_high_power"
+	CmdReboot             = "reboot"
+	CmdFactoryReset       = "factory_reset"
+	CmdUpdatePassword     = "update_pwd"
+	CmdNetConfig          = "net_config"
+	CmdSetTargetFreq      = "set_target_freq"
+	CmdEnableFastBoot     = "enable_btminer_fast_boot"
+	CmdDisableFastBoot    = "disable_btminer_fast_boot"
+	CmdEnableWebPools     = "enable_web_pools"
+	CmdDisableWebPools    = "disable_web_pools"
+	CmdSetHostname        = "set_hostname"
+	CmdSetPowerPercent    = "set_power_pct"
+	CmdSetPowerPercentV2  = "set_power_pct_v2"
+	CmdSetTempOffset      = "set_temp_offset"
+	CmdAdjustPowerLimit   = "adjust_power_limit"
+	CmdAdjustUpfreqSpeed  = "adjust_upfreq_speed"
+	CmdSetPowerOffCool    = "set_poweroff_cool"
+	CmdSetFanZeroSpeed    = "set_fan_zero_speed"
+	CmdEnableBTMinerInit  = "enable_btminer_init"
+	CmdDisableBTMinerInit = "disable_btminer_init"
+	CmdUpdateFirmware     = "update_firmware"
+	CmdDownloadLogs       = "download_logs"
+)
+
+// CommandKind tells read-only commands, sent in plaintext, from encrypted write commands.
+type CommandKind int
+
+const (
+	CommandRead CommandKind = iota + 1
+	CommandWrite
+)
+
+func (k CommandKind) String() string {
+	switch k {
+	case CommandRead:
+		return "read"
+	case CommandWrite:
+		return "write"
+	}
+	return fmt.Sprintf("CommandKind(%d)", int(k))
+}
+
+// ParamType is the type of a command parameter. The firmware accepts integers and booleans
+// either as JSON values or as strings.
+type ParamType int
+
+const (
+	ParamString ParamType = iota + 1
+	ParamInt
+	// ParamBool is sent as "0" or "1".
+	ParamBool
+)
+
+// Param describes a parameter of a command.
+type Param struct {
+	Name     string
+	Type     ParamType
+	Required bool
+	// Min and Max bound a ParamInt; both zero means unbounded.
+	Min, Max int
+}
+
+// Command describes a command of the miner API.
+type Command struct {
+	Name   string
+	Kind   CommandKind
+	Params []Param
+	// MinAPIVersion is the oldest api_ver known to support the command.
+	MinAPIVersion string
+	// Idempotent is set for write commands that set an absolute value, so sending them twice
+	// after an ambiguous failure is harmless. Read commands are always safe to repeat.
+	Idempotent bool
+}
+
+// commands is the registry of known commands, by name.
+var commands = indexCommands([]Command{
+	{Name: CmdSummary, Kind: CommandRead, MinAPIVersion: "2.0.0"},
+	{Name: CmdPools, Kind: CommandRead, MinAPIVersion: "2.0.0"},
+	{Name: CmdEdevs, Kind: CommandRead, MinAPIVersion: "2.0.0"},
+	{Name: CmdDevDetails, Kind: CommandRead, MinAPIVersion: "2.0.0"},
+	{Name: CmdGetPSU, Kind: CommandRead, MinAPIVersion: "2.0.0"},
+	{Name: CmdGetVersion, Kind: CommandRead, MinAPIVersion: "2.0.0"},
+	{Name: CmdStatus, Kind: CommandRead, MinAPIVersion: "2.0.0"},
+	{Name: CmdGetMinerInfo, Kind: CommandRead, MinAPIVersion: "2.0.0", Params: []Param{
+		{Name: "info", Type: ParamString},
+	}},
+	{Name: CmdGetErrorCode, Kind: CommandRead, MinAPIVersion: "2.0.0"},
+	{Name: CmdGetToken, Kind: CommandRead, MinAPIVersion: "2.0.0"},
+
+	{Name: CmdUpdatePools, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true, Params: []Param{
+		{Name: "pool1", Type: ParamString, Required: true},
+		{Name: "worker1", Type: ParamString, Required: true},
+		{Name: "passwd1", Type: ParamString, Required: true},
+		{Name: "pool2", Type: ParamString},
+		{Name: "worker2", Type: ParamString},
+		{Name: "passwd2", Type: ParamString},
+		{Name: "pool3", Type: ParamString},
+		{Name: "worker3", Type: ParamString},
+		{Name: "passwd3", Type: ParamString},
+	}},
+	{Name: CmdRestartBTMiner, Kind: CommandWrite, MinAPIVersion: "2.0.0"},
+	{Name: CmdPowerOff, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true, Params: []Param{
+		{Name: "respbefore", Type: ParamString},
+	}},
+	{Name: CmdPowerOn, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true},
+	{Name: CmdSetLED, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true, Params: []Param{
+		// Either param ("auto") or the custom blink settings.
+		{Name: "param", Type: ParamString},
+		{Name: "color", Type: ParamString},
+		{Name: "period", Type: ParamInt},
+		{Name: "duration", Type: ParamInt},
+		{Name: "start", Type: ParamInt},
+	}},
+	{Name: CmdSetLowPower, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true},
+	{Name: CmdSetNormalPower, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true},
+	{Name: CmdSetHighPower, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true},
+	{Name: CmdReboot, Kind: CommandWrite, MinAPIVersion: "2.0.0"},
+	{Name: CmdFactoryReset, Kind: CommandWrite, MinAPIVersion: "2.0.0"},
+	{Name: CmdUpdatePassword, Kind: CommandWrite, MinAPIVersion: "2.0.0", Params: []Param{
+		{Name: "old", Type: ParamString, Required: true},
+		{Name: "new", Type: ParamString, Required: true},
+	}},
+	{Name: CmdNetConfig, Kind: CommandWrite, MinAPIVersion: "2.0.0", Params: []Param{
+		// Either param ("dhcp") or the static settings.
+		{Name: "param", Type: ParamString},
+		{Name: "ip", Type: ParamString},
+		{Name: "mask", Type: ParamString},
+		{Name: "gate", Type: ParamString},
+		{Name: "dns", Type: ParamString},
+		{Name: "host", Type: ParamString},
+	}},
+	{Name: CmdSetTargetFreq, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true, Params: []Param{
+		{Name: "percent", Type: ParamInt, Required: true, Min: -100, Max: 100},
+	}},
+	{Name: CmdEnableFastBoot, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true},
+	{Name: CmdDisableFastBoot, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true},
+	{Name: CmdEnableWebPools, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true},
+	{Name: CmdDisableWebPools, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true},
+	{Name: CmdSetHostname, Kind: CommandWrite, MinAPIVersion: "2.0.0", Idempotent: true, Params: []Param{
+		{Name: "hostname", Type: ParamString, Required: true},
+	}},
+	{Name: CmdSetPowerPercent, Kind: CommandWrite, MinAPIVersion: "2.0.2", Idempotent: true, Params: []Param{
+		{Name: "percent", Type: ParamInt, Required: true, Min: 0, Max: 100},
+	}},
+	{Name: CmdSetPowerPercentV2, Kind: CommandWrite, MinAPIVersion: "2.0.5", Idempotent: true, Params: []Param{
+		{Name: "percent", Type: ParamInt, Required: true, Min: 0, Max: 100},
+	}},
+	{Name: CmdSetTempOffset, Kind: CommandWrite, MinAPIVersion: "2.0.4", Idempotent: true, Params: []Param{
+		{Name: "temp_offset", Type: ParamInt, Required: true, Min: -30, Max: 0},
+	}},
+	{Name: CmdAdjustPowerLimit, Kind: CommandWrite, MinAPIVersion: "2.0.1", Idempotent: true, Params: []Param{
+		{Name: "power_limit", Type: ParamInt, Required: true, Min: 0, Max: 99999},
+	}},
+	{Name: CmdAdjustUpfreqSpeed, Kind: CommandWrite, MinAPIVersion: "2.0.4", Idempotent: true, Params: []Param{
+		{Name: "upfreq_speed", Type: ParamInt, Required: true, Min: 0, Max: 9},
+	}},
+	{Name: CmdSetPowerOffCool, Kind: CommandWrite, MinAPIVersion: "2.0.4", Idempotent: true, Params: []Param{
+		{Name: "poweroff_cool", Type: ParamBool, Required: true},
+	}},
+	{Name: CmdSetFanZeroSpeed, Kind: CommandWrite, MinAPIVersion: "2.0.5", Idempotent: true, Params: []Param{
+		{Name: "fan_zero_speed", Type: ParamBool, Required: true},
+	}},
+	{Name: CmdEnableBTMinerInit, Kind: CommandWrite, MinAPIVersion: "2.0.1", Idempotent: true},
+	{Name: CmdDisableBTMinerInit, Kind: CommandWrite, MinAPIVersion: "2.0.1", Idempotent: true},
+	{Name: CmdUpdateFirmware, Kind: CommandWrite, MinAPIVersion: "2.0.0"},
+	{Name: CmdDownloadLogs, Kind: CommandWrite, MinAPIVersion: "2.0.0"},
+})
+
+func indexCommands(list []Command) map[string]Command {
+	m := make(map[string]Command, len(list))
+	for _, c := range list {
+		if _, dup := m[c.Name]; dup {
+			panic("client: command registered twice: " + c.Name)
+		}
+		m[c.Name] = c
+	}
+	return m
+}
+
+// LookupCommand returns the registry entry for name.
+func LookupCommand(name string) (Command, bool) {
+	c, ok := commands[name]
+	return c, ok
+}
+
+// Commands returns every registered command, sorted by name.
+func Commands() []Command {
+	list := slices.Collect(maps.Values(commands))
+	slices.SortFunc(list, func(a, b Command) int {
+		switch {
+		case a.Name < b.Name:
+			return -1
+		case a.Name > b.Name:
+			return 1
+		}
+		return 0
+	})
+	return list
+}
+
+// IsIdempotent reports whether the write command cmd may be re-sent after an ambiguous failure.
+// It suits transport.RetryPolicy.Idempotent; unknown commands are never re-sent. The transport
+// keeps no list of its own, so this is what makes writes retryable when passed with
+// transport.WithIdempotent, as the wmapi package does.
+func IsIdempotent(cmd string) bool {
+	return commands[cmd].Idempotent
+}
+
+// Validate checks that the command is sent as kind with params that match its schema.
+func (c Command) Validate(kind CommandKind, params map[string]any) error {
+	if kind != c.Kind {
+		return fmt.Errorf("%w: %s is a %s command, not a %s command", ErrInvalidArgument, c.Name, c.Kind, kind)
+	}
+	for _, p := range c.Params {
+		v, ok := params[p.Name]
+		if !ok {
+			if p.Required {
+				return fmt.Errorf("%w: %s requires parameter %s", ErrInvalidArgument, c.Name, p.Name)
+			}
+			continue
+		}
+		if err := p.check(v); err != nil {
+			return fmt.Errorf("%w: %s parameter %s: %w", ErrInvalidArgument, c.Name, p.Name, err)
+		}
+	}
+	for name := range params {
+		if !slices.ContainsFunc(c.Params, func(p Param) bool { return p.Name == name }) {
+			return fmt.Errorf("%w: %s has no parameter %s", ErrInvalidArgument, c.Name, name)
+		}
+	}
+	return nil
+}
+
+// check reports whether v is a valid value for p.
+func (p Param) check(v any) error {
+	switch p.Type {
+	case ParamString:
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("want a string, got %T", v)
+		}
+	case ParamInt:
+		n, ok := intValue(v)
+		if !ok {
+			return fmt.Errorf("want an integer, got %v", v)
+		}
+		if (p.Min != 0 || p.Max != 0) && (n < p.Min || n > p.Max) {
+			return fmt.Errorf("%d is outside [%d, %d]", n, p.Min, p.Max)
+		}
+	case ParamBool:
+		switch v {
+		case true, false, "0", "1":
+		default:
+			return fmt.Errorf("want a boolean, got %v", v)
+		}
+	}
+	return nil
+}
+
+// intValue converts the ways an integer parameter may be passed.
+func intValue(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		// int(n) is undefined outside the int64 range, which also rules out NaN and ±Inf.
+		if !(n >= math.MinInt64 && n < math.MaxInt64) {
+			return 0, false
+		}
+		return int(n), n == math.Trunc(n)
+	case string:
+		i, err := strconv.Atoi(n)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// validateCommand checks a known command against the registry. Commands the registry does not
+// know, such as API 3.x method names, are passed through unchecked.
+func validateCommand(cmd string, kind CommandKind, params map[string]any) error {
+	c, ok := commands[cmd]
+	if !ok {
+		return nil
+	}
+	return c.Validate(kind, params)
+}
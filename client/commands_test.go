@@ -0,0 +1,335 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/netip"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// sentCommand is a command as recordingAPI saw it go out.
+type sentCommand struct {
+	Cmd    string
+	Kind   client.CommandKind
+	Params map[string]any
+}
+
+// recordingAPI passes every command on to the real transport and records it.
+type recordingAPI struct {
+	*transport.WhatsminerAPI
+
+	mu   sync.Mutex
+	sent []sentCommand
+}
+
+func (a *recordingAPI) record(cmd string, kind client.CommandKind, params map[string]any) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sent = append(a.sent, sentCommand{Cmd: cmd, Kind: kind, Params: params})
+}
+
+func (a *recordingAPI) GetReadOnlyRaw(ctx context.Context, token *transport.WhatsminerAccessToken, cmd string, params map[string]any) ([]byte, error) {
+	a.record(cmd, client.CommandRead, params)
+	return a.WhatsminerAPI.GetReadOnlyRaw(ctx, token, cmd, params)
+}
+
+func (a *recordingAPI) ExecCommandRaw(ctx context.Context, token *transport.WhatsminerAccessToken, cmd string, params map[string]any) ([]byte, error) {
+	a.record(cmd, client.CommandWrite, params)
+	return a.WhatsminerAPI.ExecCommandRaw(ctx, token, cmd, params)
+}
+
+func (a *recordingAPI) ExecCommandUpload(ctx context.Context, token *transport.WhatsminerAccessToken, cmd string, params map[string]any, body io.Reader, size int64, progress func(sent int64)) ([]byte, error) {
+	a.record(cmd, client.CommandWrite, params)
+	return a.WhatsminerAPI.ExecCommandUpload(ctx, token, cmd, params, body, size, progress)
+}
+
+func (a *recordingAPI) ExecCommandDownload(ctx context.Context, token *transport.WhatsminerAccessToken, cmd string, params map[string]any, dst io.Writer) (int64, error) {
+	a.record(cmd, client.CommandWrite, params)
+	return a.WhatsminerAPI.ExecCommandDownload(ctx, token, cmd, params, dst)
+}
+
+// TestMethodsSendRegistryCommands calls every ReadAPI and WriteAPI method against wmapisim and
+// checks that each command it sends is registered with the kind it was sent as and only the
+// parameters the registry lists, including all required ones.
+func TestMethodsSendRegistryCommands(t *testing.T) {
+	defer func(interval time.Duration) { client.FirmwarePollInterval = interval }(client.FirmwarePollInterval)
+	client.FirmwarePollInterval = 10 * time.Millisecond
+
+	static := client.CustomNetworkSettings{
+		Address: netip.MustParsePrefix("192.168.1.20/24"),
+		Gateway: netip.MustParseAddr("192.168.1.1"),
+		DNS:     netip.MustParseAddr("192.168.1.1"),
+	}
+	image := bytes.Repeat([]byte("firmware"), 1024)
+
+	tests := []struct {
+		name string
+		// want are the commands the method sends, in order.
+		want []string
+		call func(ctx context.Context, r *client.ReadAPI, w *client.WriteAPI) error
+	}{
+		{"Read.Summary", []string{client.CmdSummary}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.SummaryContext(ctx)
+			return err
+		}},
+		{"Read.Pools", []string{client.CmdPools}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.PoolsContext(ctx)
+			return err
+		}},
+		{"Read.Edevs", []string{client.CmdEdevs}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.EdevsContext(ctx)
+			return err
+		}},
+		{"Read.DevDetails", []string{client.CmdDevDetails}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.DevDetailsContext(ctx)
+			return err
+		}},
+		{"Read.PSU", []string{client.CmdGetPSU}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.PSUContext(ctx)
+			return err
+		}},
+		{"Read.Version", []string{client.CmdGetVersion}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.VersionContext(ctx)
+			return err
+		}},
+		{"Read.Status", []string{client.CmdStatus}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.StatusContext(ctx)
+			return err
+		}},
+		{"Read.MinerInfo", []string{client.CmdGetMinerInfo}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.MinerInfoContext(ctx)
+			return err
+		}},
+		{"Read.ErrorCode", []string{client.CmdGetErrorCode}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.ErrorCodeContext(ctx)
+			return err
+		}},
+		{"Read.ErrorCodes", []string{client.CmdGetErrorCode}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.ErrorCodesContext(ctx)
+			return err
+		}},
+		{"Read.Hashboards", []string{client.CmdEdevs, client.CmdDevDetails}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.HashboardsContext(ctx)
+			return err
+		}},
+		{"Read.Telemetry", []string{client.CmdSummary, client.CmdEdevs, client.CmdGetPSU}, func(ctx context.Context, r *client.ReadAPI, _ *client.WriteAPI) error {
+			_, err := r.TelemetryContext(ctx)
+			return err
+		}},
+
+		{"Write.Pools", []string{client.CmdUpdatePools}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.PoolsContext(ctx, client.Pool{URL: "stratum+tcp://pool:3333", Worker: "w.1", Password: "x"})
+			return err
+		}},
+		{"Write.Restart", []string{client.CmdRestartBTMiner}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.RestartContext(ctx)
+			return err
+		}},
+		{"Write.PowerOffHashboard", []string{client.CmdPowerOff}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.PowerOffHashboardContext(ctx)
+			return err
+		}},
+		{"Write.PowerOnHashboard", []string{client.CmdPowerOn}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.PowerOnHashboardContext(ctx)
+			return err
+		}},
+		{"Write.ManageLedRestore", []string{client.CmdSetLED}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.ManageLedRestoreContext(ctx, "auto")
+			return err
+		}},
+		{"Write.ManageLedCustom", []string{client.CmdSetLED}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.ManageLedCustomContext(ctx, client.CustomLedSettings{Color: "red", Period: 1000, Duration: 500})
+			return err
+		}},
+		{"Write.SwitchPowerMode", []string{client.CmdSetHighPower}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.SwitchPowerModeContext(ctx, client.HighPower)
+			return err
+		}},
+		{"Write.RebootSystem", []string{client.CmdReboot}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.RebootSystemContext(ctx)
+			return err
+		}},
+		{"Write.RestoreFactorySettings", []string{client.CmdFactoryReset}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.RestoreFactorySettingsContext(ctx)
+			return err
+		}},
+		{"Write.ModifyPassword", []string{client.CmdUpdatePassword}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.ModifyPasswordContext(ctx, "admin", "new_pwd")
+			return err
+		}},
+		{"Write.NetworkSetDHCP", []string{client.CmdNetConfig}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.NetworkSetDHCPContext(ctx)
+			return err
+		}},
+		{"Write.NetworkSetCustom", []string{client.CmdGetMinerInfo, client.CmdNetConfig}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.NetworkSetCustomContext(ctx, static)
+			return err
+		}},
+		{"Write.TargetFreq", []string{client.CmdSetTargetFreq}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.TargetFreqContext(ctx, -10)
+			return err
+		}},
+		{"Write.EnableFastboot", []string{client.CmdEnableFastBoot}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.EnableFastbootContext(ctx)
+			return err
+		}},
+		{"Write.Disablefastboot", []string{client.CmdDisableFastBoot}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.DisablefastbootContext(ctx)
+			return err
+		}},
+		{"Write.EnableWebPools", []string{client.CmdEnableWebPools}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.EnableWebPoolsContext(ctx)
+			return err
+		}},
+		{"Write.DisableWebPools", []string{client.CmdDisableWebPools}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.DisableWebPoolsContext(ctx)
+			return err
+		}},
+		{"Write.ChangeHostName", []string{client.CmdSetHostname}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.ChangeHostNameContext(ctx, "miner-1")
+			return err
+		}},
+		{"Write.PowerPercent", []string{client.CmdSetPowerPercent}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.PowerPercentContext(ctx, 80)
+			return err
+		}},
+		{"Write.PowerPercentV2", []string{client.CmdSetPowerPercentV2}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.PowerPercentV2Context(ctx, 80)
+			return err
+		}},
+		{"Write.TempOffset", []string{client.CmdSetTempOffset}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.TempOffsetContext(ctx, -5)
+			return err
+		}},
+		{"Write.AdjPowerLimit", []string{client.CmdAdjustPowerLimit}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.AdjPowerLimitContext(ctx, 3000)
+			return err
+		}},
+		{"Write.AdjUpfreqSpeed", []string{client.CmdAdjustUpfreqSpeed}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.AdjUpfreqSpeedContext(ctx, 5)
+			return err
+		}},
+		{"Write.PowerOffCool", []string{client.CmdSetPowerOffCool}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.PowerOffCoolContext(ctx, true)
+			return err
+		}},
+		{"Write.FanZeroSpeed", []string{client.CmdSetFanZeroSpeed}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.FanZeroSpeedContext(ctx, true)
+			return err
+		}},
+		{"Write.DisableBTMinerInit", []string{client.CmdDisableBTMinerInit}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.DisableBTMinerInitContext(ctx)
+			return err
+		}},
+		{"Write.EnableBTMinerInit", []string{client.CmdEnableBTMinerInit}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.EnableBTMinerInitContext(ctx)
+			return err
+		}},
+		{"Write.DownloadLogs", []string{client.CmdDownloadLogs}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.DownloadLogs(ctx, io.Discard)
+			return err
+		}},
+		{"Write.UpdateFirmware", []string{client.CmdGetVersion, client.CmdUpdateFirmware, client.CmdGetVersion}, func(ctx context.Context, _ *client.ReadAPI, w *client.WriteAPI) error {
+			_, err := w.UpdateFirmware(ctx, bytes.NewReader(image), int64(len(image)), nil)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := wmapisim.DefaultState()
+			// The static configuration keeps the miner's address, so no other miner is probed.
+			state.Network.IP = static.Address.Addr().String()
+			sim := wmapisim.NewServer("admin", state)
+			if err := sim.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer sim.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			api := &recordingAPI{WhatsminerAPI: transport.NewWhatsminerAPI()}
+			token, err := api.NewAccessToken(ctx, sim.Host(), sim.Port(), sim.Password())
+			if err != nil {
+				t.Fatalf("NewAccessToken: %v", err)
+			}
+			defer token.Close()
+			read := &client.ReadAPI{API: api.WhatsminerAPI, Token: token, Protocol: api}
+			write := &client.WriteAPI{API: api.WhatsminerAPI, Token: token, Protocol: api}
+
+			if err := tt.call(ctx, read, write); err != nil {
+				t.Fatalf("call failed: %v", err)
+			}
+
+			var got []string
+			for _, sent := range api.sent {
+				got = append(got, sent.Cmd)
+				checkRegistered(t, sent)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sent %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// checkRegistered reports an error unless sent matches its registry entry.
+func checkRegistered(t *testing.T, sent sentCommand) {
+	t.Helper()
+	c, ok := client.LookupCommand(sent.Cmd)
+	if !ok {
+		t.Errorf("%s is not in the registry", sent.Cmd)
+		return
+	}
+	if sent.Kind != c.Kind {
+		t.Errorf("%s sent as a %s command, registered as %s", sent.Cmd, sent.Kind, c.Kind)
+	}
+	for name := range sent.Params {
+		if !slices.ContainsFunc(c.Params, func(p client.Param) bool { return p.Name == name }) {
+			t.Errorf("%s sent parameter %s, which the registry does not list", sent.Cmd, name)
+		}
+	}
+	for _, p := range c.Params {
+		if _, ok := sent.Params[p.Name]; p.Required && !ok {
+			t.Errorf("%s sent without its required parameter %s", sent.Cmd, p.Name)
+		}
+	}
+}
+
+func TestValidateIntParam(t *testing.T) {
+	cmd, ok := client.LookupCommand(client.CmdSetLED)
+	if !ok {
+		t.Fatal("set_led is not in the registry")
+	}
+	tests := []struct {
+		period  any
+		wantErr bool
+	}{
+		{500, false},
+		{int64(500), false},
+		{500.0, false},
+		{"500", false},
+		{500.5, true},
+		{"fast", true},
+		{math.NaN(), true},
+		{math.Inf(1), true},
+		{math.Inf(-1), true},
+		{1e300, true},
+	}
+	for _, tt := range tests {
+		err := cmd.Validate(client.CommandWrite, map[string]any{"color": "red", "period": tt.period, "duration": 100, "start": 0})
+		if (err != nil) != tt.wantErr || err != nil && !errors.Is(err, client.ErrInvalidArgument) {
+			t.Errorf("period %v: Validate = %v, want error %v", tt.period, err, tt.wantErr)
+		}
+	}
+}
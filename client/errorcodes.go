@@ -0,0 +1,287 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorCategory groups Whatsminer error codes by the subsystem they concern.
+type ErrorCategory string
+
+const (
+	CategoryFan          ErrorCategory = "fan"
+	CategoryPower        ErrorCategory = "power"
+	CategoryTemperature  ErrorCategory = "temperature"
+	CategoryEEPROM       ErrorCategory = "eeprom"
+	CategoryHashboard    ErrorCategory = "hashboard"
+	CategoryEnvironment  ErrorCategory = "environment"
+	CategoryControlBoard ErrorCategory = "control board"
+	CategoryFirmware     ErrorCategory = "firmware"
+	CategoryPool         ErrorCategory = "pool"
+	CategoryHashrate     ErrorCategory = "hashrate"
+	CategoryCooling      ErrorCategory = "cooling"
+	CategoryUnknown      ErrorCategory = "unknown"
+)
+
+// ErrorSeverity ranks how urgently an error code needs attention.
+type ErrorSeverity int
+
+const (
+	SeverityInfo ErrorSeverity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+func (s ErrorSeverity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON encodes the severity by name.
+func (s ErrorSeverity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// ErrorCodeInfo describes a known Whatsminer error code.
+type ErrorCodeInfo struct {
+	Code        int
+	Category    ErrorCategory
+	Severity    ErrorSeverity
+	Description string
+	Remediation string
+}
+
+// ErrorCodeEntry is one error reported by get_error_code.
+type ErrorCodeEntry struct {
+	Code int
+	// Timestamp is the miner's local time when the error was raised. The firmware does not report
+	// a time zone, so it is parsed as UTC. It is zero if the miner sent no timestamp or one that
+	// could not be parsed; RawTimestamp holds what was sent.
+	Timestamp    time.Time
+	RawTimestamp string
+	// Info is the catalog entry for Code. For unknown codes only Code and Category are set.
+	Info ErrorCodeInfo
+	// Known reports whether Code is in the catalog.
+	Known bool
+}
+
+// String formats the entry for humans, e.g. "Intake fan speed too low (120) — check ...".
+func (e ErrorCodeEntry) String() string {
+	if !e.Known {
+		return fmt.Sprintf("Unknown error (%d)", e.Code)
+	}
+	return fmt.Sprintf("%s (%d) — %s", e.Info.Description, e.Code, e.Info.Remediation)
+}
+
+// errorTimeLayout is the format of get_error_code timestamps.
+const errorTimeLayout = "2006-01-02 15:04:05"
+
+// LookupErrorCode returns the catalog entry for code.
+func LookupErrorCode(code int) (ErrorCodeInfo, bool) {
+	info, ok := errorCatalog[code]
+	return info, ok
+}
+
+// ErrorCodeList is the Msg of a get_error_code response: the reported errors joined against the
+// error code catalog, sorted by timestamp, oldest first.
+type ErrorCodeList []ErrorCodeEntry
+
+// UnmarshalJSON decodes {"error_code": [...]}. Healthy miners on some firmware send an empty
+// string, an empty list or null instead of the object; these decode as an empty list.
+func (l *ErrorCodeList) UnmarshalJSON(data []byte) error {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("unexpected error code payload: %w", err)
+	}
+
+	var items []any
+	switch v := raw.(type) {
+	case map[string]any:
+		if codes, ok := v["error_code"]; ok && codes != nil {
+			if items, ok = codes.([]any); !ok {
+				return fmt.Errorf("unexpected error code payload: error_code is %T", codes)
+			}
+		}
+	case []any:
+		items = v
+	case string, nil:
+	default:
+		return fmt.Errorf("unexpected error code payload %T", raw)
+	}
+
+	entries := make(ErrorCodeList, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case map[string]any:
+			// Usual form: {"<code>": "<timestamp>"}.
+			for code, ts := range v {
+				entry, err := newErrorCodeEntry(code)
+				if err != nil {
+					return err
+				}
+				if s, ok := ts.(string); ok {
+					entry.RawTimestamp = s
+					if t, err := time.Parse(errorTimeLayout, s); err == nil {
+						entry.Timestamp = t
+					}
+				}
+				entries = append(entries, entry)
+			}
+		case string:
+			entry, err := newErrorCodeEntry(v)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		case float64:
+			entries = append(entries, errorCodeEntry(int(v)))
+		default:
+			return fmt.Errorf("unexpected error code entry %T", item)
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b ErrorCodeEntry) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	*l = entries
+	return nil
+}
+
+// MarshalJSON encodes the list in the form the miner sends, so a response can be decoded again.
+func (l ErrorCodeList) MarshalJSON() ([]byte, error) {
+	list := make([]map[string]string, len(l))
+	for i, e := range l {
+		list[i] = map[string]string{strconv.Itoa(e.Code): e.RawTimestamp}
+	}
+	return json.Marshal(map[string]any{"error_code": list})
+}
+
+// ErrorCodes retrieves the active error codes, decoded and joined against the catalog.
+func (r *ReadAPI) ErrorCodes() ([]ErrorCodeEntry, error) {
+	return r.ErrorCodesContext(context.Background())
+}
+
+// ErrorCodesContext is like ErrorCodes but binds the request to ctx.
+func (r *ReadAPI) ErrorCodesContext(ctx context.Context) ([]ErrorCodeEntry, error) {
+	resp, err := r.ErrorCodeContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg, nil
+}
+
+func newErrorCodeEntry(code string) (ErrorCodeEntry, error) {
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return ErrorCodeEntry{}, fmt.Errorf("invalid error code %q: %w", code, err)
+	}
+	return errorCodeEntry(n), nil
+}
+
+func errorCodeEntry(code int) ErrorCodeEntry {
+	info, ok := LookupErrorCode(code)
+	if !ok {
+		info = ErrorCodeInfo{Code: code, Category: CategoryUnknown, Severity: SeverityWarning}
+	}
+	return ErrorCodeEntry{Code: code, Info: info, Known: ok}
+}
+
+// errorCatalog holds the error codes documented for Whatsminer firmware. Codes that exist once
+// per hashboard are listed with a %d placeholder for the slot and expanded for SM0-SM2.
+var errorCatalog = buildErrorCatalog([]ErrorCodeInfo{
+	{110, CategoryFan, SeverityCritical, "Intake fan speed error", "check the intake fan cable and connector, replace the fan if it does not spin"},
+	{111, CategoryFan, SeverityCritical, "Exhaust fan speed error", "check the exhaust fan cable and connector, replace the fan if it does not spin"},
+	{120, CategoryFan, SeverityWarning, "Intake fan speed too low", "check the intake fan connector and clear any obstruction"},
+	{121, CategoryFan, SeverityWarning, "Exhaust fan speed too low", "check the exhaust fan connector and clear any obstruction"},
+	{130, CategoryFan, SeverityWarning, "Intake fan speed too high", "check the fan model matches the miner and the fan control cable"},
+	{131, CategoryFan, SeverityWarning, "Exhaust fan speed too high", "check the fan model matches the miner and the fan control cable"},
+	{140, CategoryFan, SeverityWarning, "Fan speed too high", "check ambient temperature and airflow"},
+
+	{200, CategoryPower, SeverityCritical, "Power supply not detected", "check the PSU communication cable to the control board"},
+	{201, CategoryPower, SeverityCritical, "Power supply does not match configuration", "install the PSU model expected by the firmware or update the firmware"},
+	{202, CategoryPower, SeverityCritical, "Power supply output voltage error", "power cycle the miner, replace the PSU if the error persists"},
+	{203, CategoryPower, SeverityWarning, "Power supply protecting due to high environment temperature", "lower the intake temperature and improve airflow"},
+	{204, CategoryPower, SeverityWarning, "Power supply current protecting due to high environment temperature", "lower the intake temperature and improve airflow"},
+	{205, CategoryPower, SeverityCritical, "Power supply current error", "power cycle the miner, replace the PSU if the error persists"},
+	{206, CategoryPower, SeverityCritical, "Power supply input voltage too low", "check the input voltage at the PDU"},
+	{207, CategoryPower, SeverityCritical, "Power supply input current protecting", "check the input power quality and the power cord"},
+	{210, CategoryPower, SeverityCritical, "Power supply error status", "read the PSU status with get_psu and replace the PSU if it stays in error"},
+	{213, CategoryPower, SeverityWarning, "Power supply input and output power mismatch", "check the input voltage, replace the PSU if the error persists"},
+	{216, CategoryPower, SeverityWarning, "Power supply output unchanged for too long", "restart btminer, replace the PSU if the error persists"},
+	{217, CategoryPower, SeverityCritical, "Power supply enable error", "check the PSU communication cable, replace the PSU if the error persists"},
+	{218, CategoryPower, SeverityWarning, "Input voltage below 230V in high power mode", "switch to normal power mode or raise the supply voltage"},
+	{233, CategoryPower, SeverityCritical, "Power supply over-temperature protection", "lower the intake temperature and check the PSU fan"},
+
+	{300, CategoryTemperature, SeverityCritical, "SM%d temperature sensor detection error", "reseat the hashboard signal cable, replace the hashboard if the error persists"},
+	{320, CategoryTemperature, SeverityCritical, "SM%d temperature reading error", "reseat the hashboard signal cable, replace the hashboard if the error persists"},
+	{329, CategoryTemperature, SeverityWarning, "Control board temperature sensor communication error", "replace the control board if the error persists"},
+	{350, CategoryTemperature, SeverityCritical, "SM%d temperature protection", "lower the intake temperature and check the fans"},
+	{360, CategoryTemperature, SeverityCritical, "Hashboard temperature too high", "lower the intake temperature and check the fans"},
+
+	{410, CategoryEEPROM, SeverityCritical, "SM%d EEPROM detection error", "reseat the hashboard signal cable, reprogram or replace the hashboard"},
+	{420, CategoryEEPROM, SeverityCritical, "SM%d EEPROM parse error", "reprogram the hashboard EEPROM"},
+	{430, CategoryEEPROM, SeverityCritical, "SM%d chip bin type mismatch", "use hashboards of the same bin in one miner"},
+	{440, CategoryEEPROM, SeverityCritical, "SM%d EEPROM chip number mismatch", "reprogram the hashboard EEPROM"},
+	{450, CategoryEEPROM, SeverityCritical, "SM%d EEPROM transfer error", "reseat the hashboard signal cable, replace the hashboard if the error persists"},
+
+	{510, CategoryHashboard, SeverityCritical, "SM%d miner type error", "use hashboards matching the miner model"},
+	{530, CategoryHashboard, SeverityCritical, "SM%d not found", "reseat the hashboard power and signal cables"},
+	{5110, CategoryHashboard, SeverityWarning, "SM%d frequency ramp-up timeout", "restart btminer, check the hashboard if the error persists"},
+
+	{600, CategoryEnvironment, SeverityWarning, "Environment temperature too high", "lower the intake temperature"},
+	{610, CategoryEnvironment, SeverityWarning, "Environment temperature too high for high performance mode", "lower the intake temperature or switch to normal power mode"},
+
+	{701, CategoryControlBoard, SeverityCritical, "Control board does not support the chip", "update the firmware or replace the control board"},
+	{710, CategoryControlBoard, SeverityWarning, "Control board rebooted unexpectedly", "check the control board power supply, update the firmware"},
+
+	{800, CategoryFirmware, SeverityCritical, "btminer checksum error", "reflash the firmware"},
+	{801, CategoryFirmware, SeverityCritical, "system-monitor checksum error", "reflash the firmware"},
+	{802, CategoryFirmware, SeverityCritical, "remote-daemon checksum error", "reflash the firmware"},
+	{8410, CategoryFirmware, SeverityCritical, "Software version error", "reflash a firmware matching the miner model"},
+
+	{2010, CategoryPool, SeverityCritical, "All pools are disabled", "check the pool configuration and network access to the pools"},
+	{2020, CategoryPool, SeverityWarning, "Pool 1 connection failed", "check the pool URL and network access to the pool"},
+	{2021, CategoryPool, SeverityWarning, "Pool 2 connection failed", "check the pool URL and network access to the pool"},
+	{2022, CategoryPool, SeverityWarning, "Pool 3 connection failed", "check the pool URL and network access to the pool"},
+	{2030, CategoryPool, SeverityWarning, "High rejection rate on pool", "check network latency to the pool and the pool difficulty"},
+	{2040, CategoryPool, SeverityInfo, "No pool supports ASICBoost", "use a pool that supports version rolling"},
+
+	{2310, CategoryHashrate, SeverityWarning, "Hashrate too low", "check hashboard temperatures and error codes for failing boards"},
+	{2320, CategoryHashrate, SeverityWarning, "Hashrate too low", "check the input voltage and the power mode, then hashboard error codes"},
+	// The vendor table gives 2340 and 2350 the same description.
+	{2340, CategoryHashrate, SeverityWarning, "Hashrate loss too high", "check for hashboards with dead chips or that dropped out"},
+	{2350, CategoryHashrate, SeverityWarning, "Hashrate loss too high", "check for hashboards with dead chips or that dropped out"},
+
+	{5070, CategoryCooling, SeverityCritical, "SM%d water velocity abnormal", "check the coolant pump and flow to the hashboard"},
+})
+
+// buildErrorCatalog indexes infos by code, expanding per-hashboard descriptions for slots 0-2.
+func buildErrorCatalog(infos []ErrorCodeInfo) map[int]ErrorCodeInfo {
+	catalog := make(map[int]ErrorCodeInfo, len(infos))
+	for _, info := range infos {
+		if !strings.Contains(info.Description, "%d") {
+			catalog[info.Code] = info
+			continue
+		}
+		for slot := range 3 {
+			expanded := info
+			expanded.Code = info.Code + slot
+			expanded.Description = fmt.Sprintf(info.Description, slot)
+			catalog[expanded.Code] = expanded
+		}
+	}
+	return catalog
+}
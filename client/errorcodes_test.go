@@ -0,0 +1,42 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestErrorCodeListDecode(t *testing.T) {
+	tests := []struct {
+		msg   string
+		codes []int
+	}{
+		{`{"error_code":[{"2320":"2024-01-02 03:04:05"},{"110":"2024-01-01 00:00:00"}]}`, []int{110, 2320}},
+		{`{"error_code":["530",2010]}`, []int{530, 2010}},
+		{`{"error_code":[]}`, nil},
+		{`{}`, nil},
+		{`""`, nil},
+		{`[]`, nil},
+		{`null`, nil},
+	}
+	for _, tt := range tests {
+		var resp ErrorResponse
+		if err := json.Unmarshal([]byte(`{"STATUS":"S","Code":133,"Msg":`+tt.msg+`}`), &resp); err != nil {
+			t.Errorf("Msg %s: %v", tt.msg, err)
+			continue
+		}
+		if len(resp.Msg) != len(tt.codes) {
+			t.Errorf("Msg %s: got %d entries, want %d", tt.msg, len(resp.Msg), len(tt.codes))
+			continue
+		}
+		for i, e := range resp.Msg {
+			if e.Code != tt.codes[i] || !e.Known {
+				t.Errorf("Msg %s: entry %d = %d (known %v), want known %d", tt.msg, i, e.Code, e.Known, tt.codes[i])
+			}
+		}
+	}
+
+	var resp ErrorResponse
+	if err := json.Unmarshal([]byte(`{"STATUS":"S","Msg":{"error_code":"2010"}}`), &resp); err == nil {
+		t.Error("error_code that is not a list decoded without an error")
+	}
+}
@@ -0,0 +1,19 @@
+package client
+
+import "errors"
+
+// ErrInvalidArgument is returned when a method rejects its input before anything is sent to the
+// miner. Errors from the miner itself are the typed errors of the transport package.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// ErrFirmwareUnchanged is returned by UpdateFirmware when the miner rebooted after the upload but
+// reports the firmware version it had before.
+var ErrFirmwareUnchanged = errors.New("firmware version unchanged after the update")
+
+// ErrFirmwareRejected is returned by UpdateFirmware when the miner keeps running the firmware it
+// had before without rebooting, as it does when it rejects the uploaded image.
+var ErrFirmwareRejected = errors.New("firmware image not installed")
+
+// ErrNotReflected is returned by the Verify variants of write methods when the miner accepted a
+// change but its readings did not show it before the context ended.
+var ErrNotReflected = errors.New("change not reflected by the miner")
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+)
+
+// FirmwareStage is the phase of a firmware update.
+type FirmwareStage int
+
+const (
+	// FirmwareUploading is reported after every uploaded chunk of the image.
+	FirmwareUploading FirmwareStage = iota + 1
+	// FirmwareInstalling is reported once the image is uploaded, while the miner installs it and
+	// reboots.
+	FirmwareInstalling
+	// FirmwareDone is reported when the miner is back after installing the image.
+	FirmwareDone
+)
+
+func (s FirmwareStage) String() string {
+	switch s {
+	case FirmwareUploading:
+		return "uploading"
+	case FirmwareInstalling:
+		return "installing"
+	case FirmwareDone:
+		return "done"
+	}
+	return fmt.Sprintf("FirmwareStage(%d)", int(s))
+}
+
+// FirmwareProgress is passed to the UpdateFirmware callback.
+type FirmwareProgress struct {
+	Stage FirmwareStage
+	// Sent and Total are the uploaded and total image size in bytes.
+	Sent  int64
+	Total int64
+	// FwVer is the firmware version the miner reports, set once the stage is FirmwareDone.
+	FwVer string
+}
+
+// FirmwarePollInterval is how often UpdateFirmware asks the miner for its version while it
+// installs the image and reboots.
+var FirmwarePollInterval = 5 * time.Second
+
+// FirmwareRebootTimeout is how long UpdateFirmware keeps polling a miner that answers with the
+// firmware version it had before and has not rebooted, before it gives up with
+// ErrFirmwareRejected. It allows for the install that runs before the reboot.
+var FirmwareRebootTimeout = 5 * time.Minute
+
+// UpdateFirmware uploads a firmware image of size bytes with update_firmware and waits until the
+// miner is back after installing it: either it reports a fw_ver different from the one it had
+// before, or its uptime shows it rebooted after the upload. Polls that fail while the miner is
+// down prove nothing and are retried. progress, if not nil, is called from the calling goroutine
+// as the update advances. ctx bounds the whole update including the reboot, so it should allow
+// several minutes. A miner that keeps answering with its old version without rebooting for
+// FirmwareRebootTimeout has rejected the image; the error then wraps ErrFirmwareRejected.
+//
+// It returns the get_version response of the installed firmware. If the miner rebooted but
+// reports the fw_ver it had before, the response is returned with an error wrapping
+// ErrFirmwareUnchanged; re-flashing the installed image, a common repair, ends this way. Only API
+// 2.x miners support uploads; others fail with transport.ErrUnsupported.
+func (w *WriteAPI) UpdateFirmware(ctx context.Context, image io.Reader, size int64, progress func(FirmwareProgress)) (*VersionResponse, error) {
+	uploader, ok := w.protocol().(transport.Uploader)
+	if !ok {
+		return nil, fmt.Errorf("%w: firmware upload", transport.ErrUnsupported)
+	}
+	if err := w.Capabilities.check(CmdUpdateFirmware); err != nil {
+		return nil, err
+	}
+	if image == nil || size <= 0 {
+		return nil, fmt.Errorf("%w: firmware image must not be empty", ErrInvalidArgument)
+	}
+
+	report := func(p FirmwareProgress) {
+		if progress != nil {
+			progress(p)
+		}
+	}
+
+	r := &ReadAPI{API: w.API, Token: w.Token, Protocol: w.Protocol}
+	before, err := r.VersionContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read firmware version before the update: %w", err)
+	}
+
+	start := time.Now()
+	_, err = uploader.ExecCommandUpload(ctx, w.Token, CmdUpdateFirmware, nil, image, size, func(sent int64) {
+		report(FirmwareProgress{Stage: FirmwareUploading, Sent: sent, Total: size})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("firmware upload failed: %w", err)
+	}
+	report(FirmwareProgress{Stage: FirmwareInstalling, Sent: size, Total: size})
+
+	ticker := time.NewTicker(FirmwarePollInterval)
+	defer ticker.Stop()
+	// unchangedSince is when the miner first answered with its old version without having rebooted.
+	var unchangedSince time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("miner did not come back after the firmware update (was %s): %w", before.Msg.FwVer, ctx.Err())
+		case <-ticker.C:
+		}
+
+		// The miner refuses connections while it reboots; keep polling.
+		after, err := r.VersionContext(ctx)
+		if err != nil || after.Msg.FwVer == "" {
+			continue
+		}
+		if after.Msg.FwVer == before.Msg.FwVer {
+			if !bootedSince(ctx, r, start) {
+				if unchangedSince.IsZero() {
+					unchangedSince = time.Now()
+				}
+				if time.Since(unchangedSince) >= FirmwareRebootTimeout {
+					return nil, fmt.Errorf("%w: miner still runs %s without having rebooted", ErrFirmwareRejected, before.Msg.FwVer)
+				}
+				continue
+			}
+			report(FirmwareProgress{Stage: FirmwareDone, Sent: size, Total: size, FwVer: after.Msg.FwVer})
+			return after, fmt.Errorf("%w: miner rebooted with %s", ErrFirmwareUnchanged, after.Msg.FwVer)
+		}
+		report(FirmwareProgress{Stage: FirmwareDone, Sent: size, Total: size, FwVer: after.Msg.FwVer})
+		return after, nil
+	}
+}
+
+// bootedSince reports whether summary shows an uptime that started after t.
+func bootedSince(ctx context.Context, r *ReadAPI, t time.Time) bool {
+	summary, err := r.SummaryContext(ctx)
+	if err != nil || len(summary.SUMMARY) == 0 || !summary.SUMMARY[0].Uptime.Valid() {
+		return false
+	}
+	uptime := time.Duration(float64(summary.SUMMARY[0].Uptime) * float64(time.Second))
+	return uptime < time.Since(t)
+}
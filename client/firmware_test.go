@@ -0,0 +1,104 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// startFirmwareSim starts a simulated miner and returns a WriteAPI for it.
+func startFirmwareSim(t *testing.T, ctx context.Context) (*wmapisim.Server, *client.WriteAPI) {
+	t.Helper()
+	sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+	sim.FirmwareInstallTime = 50 * time.Millisecond
+	if err := sim.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sim.Close() })
+
+	api := transport.NewWhatsminerAPI()
+	token, err := api.NewAccessToken(ctx, sim.Host(), sim.Port(), sim.Password())
+	if err != nil {
+		t.Fatalf("NewAccessToken: %v", err)
+	}
+	t.Cleanup(token.Close)
+	return sim, &client.WriteAPI{API: api, Token: token}
+}
+
+func TestUpdateFirmwareOutcomes(t *testing.T) {
+	defer func(interval, timeout time.Duration) {
+		client.FirmwarePollInterval, client.FirmwareRebootTimeout = interval, timeout
+	}(client.FirmwarePollInterval, client.FirmwareRebootTimeout)
+	client.FirmwarePollInterval = 10 * time.Millisecond
+	client.FirmwareRebootTimeout = 100 * time.Millisecond
+	image := bytes.Repeat([]byte("firmware"), 1024)
+
+	t.Run("rejected image with a failed poll", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sim, w := startFirmwareSim(t, ctx)
+		sim.RejectFirmware = true
+		installed := sim.State().FirmwareVersion
+
+		var done bool
+		resp, err := w.UpdateFirmware(ctx, bytes.NewReader(image), int64(len(image)), func(p client.FirmwareProgress) {
+			switch p.Stage {
+			case client.FirmwareInstalling:
+				// The first poll fails as it would while the miner is busy.
+				sim.InjectFault(wmapisim.Fault{Kind: wmapisim.FaultOverMaxConnect, Cmd: client.CmdGetVersion, Times: 1})
+			case client.FirmwareDone:
+				done = true
+			}
+		})
+		if !errors.Is(err, client.ErrFirmwareRejected) || resp != nil {
+			t.Errorf("UpdateFirmware = %v, %v, want ErrFirmwareRejected", resp, err)
+		}
+		if ctx.Err() != nil {
+			t.Error("UpdateFirmware waited until ctx ended")
+		}
+		if done {
+			t.Error("FirmwareDone reported for a rejected image")
+		}
+		if got := sim.State().FirmwareVersion; got != installed {
+			t.Errorf("simulator firmware = %s, want %s", got, installed)
+		}
+	})
+
+	t.Run("new image", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sim, w := startFirmwareSim(t, ctx)
+
+		resp, err := w.UpdateFirmware(ctx, bytes.NewReader(image), int64(len(image)), nil)
+		if err != nil {
+			t.Fatalf("UpdateFirmware: %v", err)
+		}
+		if got, want := resp.Msg.FwVer, sim.State().FirmwareVersion; got != want {
+			t.Errorf("fw_ver = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("re-flashed image", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sim, w := startFirmwareSim(t, ctx)
+
+		if _, err := w.UpdateFirmware(ctx, bytes.NewReader(image), int64(len(image)), nil); err != nil {
+			t.Fatalf("first UpdateFirmware: %v", err)
+		}
+		installed := sim.State().FirmwareVersion
+		resp, err := w.UpdateFirmware(ctx, bytes.NewReader(image), int64(len(image)), nil)
+		if !errors.Is(err, client.ErrFirmwareUnchanged) {
+			t.Fatalf("UpdateFirmware with the installed image = %v, want ErrFirmwareUnchanged", err)
+		}
+		if resp == nil || resp.Msg.FwVer != installed {
+			t.Errorf("response = %+v, want fw_ver %s", resp, installed)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// Float is a telemetry value. Besides plain numbers it accepts the "NaN", "Inf" and "-Inf" strings
+// the transport substitutes for the bare nan/inf literals some firmware emits, numbers sent as
+// strings, and null or an empty string, which decode as NaN because the value is unknown. The
+// Float fields of SummaryInfo, Edev and PoolInfo are NaN as well when the miner omits their key.
+//
+// Unlike float64, a Float holding NaN or an infinity marshals back to those strings instead of
+// failing, so responses can always be re-encoded.
+type Float float64
+
+// Valid reports whether f is a finite number, i.e. the miner actually reported a value.
+func (f Float) Valid() bool {
+	return !math.IsNaN(float64(f)) && !math.IsInf(float64(f), 0)
+}
+
+// IsNaN reports whether the miner sent nan or no value at all.
+func (f Float) IsNaN() bool {
+	return math.IsNaN(float64(f))
+}
+
+// IsInf reports whether the miner sent an infinite value.
+func (f Float) IsInf() bool {
+	return math.IsInf(float64(f), 0)
+}
+
+func (f Float) String() string {
+	switch {
+	case f.IsNaN():
+		return "NaN"
+	case math.IsInf(float64(f), 1):
+		return "Inf"
+	case math.IsInf(float64(f), -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(float64(f), 'g', -1, 64)
+}
+
+func (f Float) MarshalJSON() ([]byte, error) {
+	if !f.Valid() {
+		return json.Marshal(f.String())
+	}
+	return json.Marshal(float64(f))
+}
+
+func (f *Float) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*f = Float(math.NaN())
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*f = Float(math.NaN())
+			return nil
+		}
+		// ParseFloat understands "NaN", "Inf" and "-Inf" in any case.
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid numeric value %q", s)
+		}
+		*f = Float(v)
+		return nil
+	}
+
+	var v float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*f = Float(v)
+	return nil
+}
+
+// unmarshalNaNDefault decodes data into the struct v points to after setting its Float fields to
+// NaN, so keys missing from data leave them unknown instead of zero.
+func unmarshalNaNDefault(data []byte, v any) error {
+	s := reflect.ValueOf(v).Elem()
+	for i := range s.NumField() {
+		if f := s.Field(i); f.Type() == reflect.TypeFor[Float]() {
+			f.SetFloat(math.NaN())
+		}
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (s *SummaryInfo) UnmarshalJSON(data []byte) error {
+	type summaryInfo SummaryInfo
+	return unmarshalNaNDefault(data, (*summaryInfo)(s))
+}
+
+func (e *Edev) UnmarshalJSON(data []byte) error {
+	type edev Edev
+	return unmarshalNaNDefault(data, (*edev)(e))
+}
+
+func (p *PoolInfo) UnmarshalJSON(data []byte) error {
+	type poolInfo PoolInfo
+	return unmarshalNaNDefault(data, (*poolInfo)(p))
+}
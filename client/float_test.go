@@ -0,0 +1,68 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMissingKeysDecodeAsNaN(t *testing.T) {
+	var edevs EdevsResponse
+	data := `{"STATUS":[{"STATUS":"S"}],"DEVS":[{"Slot":0,"MHS av":61000000.5,"Effective Chips":0}]}`
+	if err := json.Unmarshal([]byte(data), &edevs); err != nil {
+		t.Fatal(err)
+	}
+	e := edevs.DEVS[0]
+	if e.MHSAv != 61000000.5 {
+		t.Errorf("MHSAv = %v, want 61000000.5", e.MHSAv)
+	}
+	if !e.MHS1M.IsNaN() {
+		t.Errorf("MHS1M = %v for a missing key, want NaN", e.MHS1M)
+	}
+	if e.EffectiveChips != 0 {
+		t.Errorf("EffectiveChips = %v, want the 0 that was sent", e.EffectiveChips)
+	}
+
+	var summary SummaryResponse
+	if err := json.Unmarshal([]byte(`{"SUMMARY":[{"Power":3100}]}`), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if s := summary.SUMMARY[0]; s.Power != 3100 || !s.PowerRate.IsNaN() {
+		t.Errorf("Power, PowerRate = %v, %v, want 3100, NaN", s.Power, s.PowerRate)
+	}
+}
+
+// TestNonFiniteNumbersDecode decodes every response type with each numeric field set to the
+// strings the transport substitutes for bare nan and inf literals.
+func TestNonFiniteNumbersDecode(t *testing.T) {
+	header := `"STATUS":"S","When":"NaN","Code":"Inf","Description":""`
+	tests := []struct {
+		name string
+		data string
+		v    any
+	}{
+		{"command", `{` + header + `,"Msg":"API command OK"}`, &CommandResponse{}},
+		{"error code", `{` + header + `,"Msg":{"error_code":[]}}`, &ErrorResponse{}},
+		{"status", `{` + header + `,"Msg":{"btmineroff":"false","power_mode":"Normal","hash_percent":"100"}}`, &StatusResponse{}},
+		{"version", `{` + header + `,"Msg":{"api_ver":"2.0.5","fw_ver":"20240101.22.REL"}}`, &VersionResponse{}},
+		{"psu", `{` + header + `,"Msg":{"name":"P221B","pin":"3320"}}`, &PSUResponse{}},
+		{"miner info", `{` + header + `,"Msg":{"ip":"10.0.0.1"}}`, &MinerInfoResponse{}},
+		{"devdetails", `{"STATUS":[{"STATUS":"S"}],"DEVDETAILS":[{"DEVDETAILS":"NaN","Name":"SM","ID":"NaN"}]}`, &DevdetailsResponse{}},
+		{"edevs", `{"STATUS":[{"STATUS":"S"}],"DEVS":[{"ASC":"NaN","Slot":"NaN","MHS av":"Inf","chip_vol_diff":"-Inf"}]}`, &EdevsResponse{}},
+		{"pools", `{"STATUS":[{"STATUS":"S"}],"POOLS":[{"POOL":"NaN","Priority":"Inf","Quota":"NaN"}]}`, &PoolsResponse{}},
+		{"summary", `{"STATUS":[{"STATUS":"S"}],"SUMMARY":[{"Power Rate":"Inf","Hash Deviation%":"NaN"}]}`, &SummaryResponse{}},
+	}
+	for _, tt := range tests {
+		if err := json.Unmarshal([]byte(tt.data), tt.v); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+
+	var edevs EdevsResponse
+	json.Unmarshal([]byte(tests[7].data), &edevs)
+	if e := edevs.DEVS[0]; !e.Slot.IsNaN() || !e.MHSAv.IsInf() {
+		t.Errorf("Slot, MHSAv = %v, %v, want NaN, Inf", e.Slot, e.MHSAv)
+	}
+	if boards := MergeHashboards(&edevs, nil); boards[0].Slot != -1 {
+		t.Errorf("Hashboard.Slot = %d for an unreported slot, want -1", boards[0].Slot)
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Read sends the read-only command cmd and decodes the response into a new T. It works for any
+// command the firmware supports, including ones this package has no method for yet. Commands in
+// the registry are checked against it first and fail with ErrInvalidArgument if they are not
+// read commands or params do not match their schema.
+func Read[T any](ctx context.Context, r *ReadAPI, cmd string, params map[string]any) (*T, error) {
+	if err := validateCommand(cmd, CommandRead, params); err != nil {
+		return nil, err
+	}
+	data, err := r.protocol().GetReadOnlyRaw(ctx, r.Token, cmd, params)
+	if err != nil {
+		return nil, err
+	}
+	return decode[T](cmd, data)
+}
+
+// Exec sends the write command cmd and decodes the decrypted response into a new T. It works for
+// any command the firmware supports, including ones this package has no method for yet. Commands
+// in the registry are checked against it first, as in Read, and against w.Capabilities.
+func Exec[T any](ctx context.Context, w *WriteAPI, cmd string, params map[string]any) (*T, error) {
+	if err := validateCommand(cmd, CommandWrite, params); err != nil {
+		return nil, err
+	}
+	if err := w.Capabilities.check(cmd); err != nil {
+		return nil, err
+	}
+	data, err := w.protocol().ExecCommandRaw(ctx, w.Token, cmd, params)
+	if err != nil {
+		return nil, err
+	}
+	return decode[T](cmd, data)
+}
+
+func decode[T any](cmd string, data []byte) (*T, error) {
+	v := new(T)
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s response: %w", cmd, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Hashboard is one hashboard, merged from its edevs and devdetails entries.
+type Hashboard struct {
+	// Slot is -1 if the firmware did not report it.
+	Slot int `json:"slot"`
+	// Serial is the PCB serial number.
+	Serial string `json:"serial"`
+	// Model and Driver come from devdetails and are empty if it had no entry for the slot.
+	Model  string `json:"model,omitempty"`
+	Driver string `json:"driver,omitempty"`
+	// Enabled is false only if the firmware reports the board disabled; API 3.x does not report it.
+	Enabled bool `json:"enabled"`
+	// Status is the firmware's state of the board, "Alive" while it works.
+	Status string `json:"status"`
+	// Hashrate is the one-minute average, or the average since mining started on firmware that does
+	// not report one, such as API 3.x.
+	Hashrate        Hashrate `json:"hashrate_ths"`
+	FactoryHashrate Hashrate `json:"factory_hashrate_ths"`
+	// Frequency is the chip frequency in MHz.
+	Frequency   Float   `json:"frequency_mhz"`
+	Temperature Celsius `json:"temperature_c"`
+	ChipTempMin Celsius `json:"chip_temp_min_c"`
+	ChipTempMax Celsius `json:"chip_temp_max_c"`
+	ChipTempAvg Celsius `json:"chip_temp_avg_c"`
+	// EffectiveChips is NaN if the firmware does not report it.
+	EffectiveChips Float `json:"effective_chips"`
+	// ChipVolDiff is the spread of chip voltages in mV.
+	ChipVolDiff Float  `json:"chip_vol_diff_mv"`
+	ChipData    string `json:"chip_data,omitempty"`
+}
+
+// Hashboards reads edevs and devdetails and merges them with MergeHashboards.
+func (r *ReadAPI) Hashboards() ([]Hashboard, error) {
+	return r.HashboardsContext(context.Background())
+}
+
+// HashboardsContext is like Hashboards but binds the requests to ctx.
+func (r *ReadAPI) HashboardsContext(ctx context.Context) ([]Hashboard, error) {
+	edevs, err := r.EdevsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	details, err := r.DevDetailsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return MergeHashboards(edevs, details), nil
+}
+
+// MergeHashboards joins the edevs entries with the devdetails entries of the same slot, matching
+// Slot against ID, and returns the boards sorted by slot. details may be nil.
+func MergeHashboards(edevs *EdevsResponse, details *DevdetailsResponse) []Hashboard {
+	if edevs == nil {
+		return nil
+	}
+	bySlot := make(map[int]DevDetail)
+	if details != nil {
+		for _, d := range details.DEVDETAILS {
+			if d.ID.Valid() {
+				bySlot[int(d.ID)] = d
+			}
+		}
+	}
+
+	boards := make([]Hashboard, len(edevs.DEVS))
+	for i, e := range edevs.DEVS {
+		boards[i] = newHashboard(e)
+		if d, ok := bySlot[boards[i].Slot]; ok {
+			boards[i].Model = d.Model
+			boards[i].Driver = d.Driver
+		}
+	}
+	slices.SortStableFunc(boards, func(a, b Hashboard) int { return a.Slot - b.Slot })
+	return boards
+}
+
+func newHashboard(e Edev) Hashboard {
+	mhs := e.MHS1M
+	if !mhs.Valid() {
+		mhs = e.MHSAv
+	}
+	slot := -1
+	if e.Slot.Valid() {
+		slot = int(e.Slot)
+	}
+	return Hashboard{
+		Slot:            slot,
+		Serial:          e.PCBSN,
+		Enabled:         !strings.EqualFold(e.Enabled, "N"),
+		Status:          e.Status,
+		Hashrate:        HashrateFromMHS(mhs),
+		FactoryHashrate: HashrateFromGHS(e.HSFactory),
+		Frequency:       e.ChipFrequency,
+		Temperature:     Celsius(e.Temperature),
+		ChipTempMin:     Celsius(e.ChipTempMin),
+		ChipTempMax:     Celsius(e.ChipTempMax),
+		ChipTempAvg:     Celsius(e.ChipTempAvg),
+		EffectiveChips:  e.EffectiveChips,
+		ChipVolDiff:     e.ChipVolDiff,
+		ChipData:        e.ChipData,
+	}
+}
+
+// BoardStatus is the outcome of a hashboard health assessment, ordered by severity.
+type BoardStatus int
+
+const (
+	BoardHealthy BoardStatus = iota
+	BoardDegraded
+	BoardFailed
+)
+
+func (s BoardStatus) String() string {
+	switch s {
+	case BoardHealthy:
+		return "healthy"
+	case BoardDegraded:
+		return "degraded"
+	case BoardFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("BoardStatus(%d)", int(s))
+}
+
+func (s BoardStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// BoardHealth is the result of Hashboard.Assess.
+type BoardHealth struct {
+	Status BoardStatus `json:"status"`
+	// Reasons explains every finding that made the board less than healthy.
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// HealthLimits are the thresholds of Hashboard.Assess. A board at or beyond a Degraded limit is
+// degraded, at or beyond a Failed limit it has failed.
+type HealthLimits struct {
+	// DegradedHashrate and FailedHashrate are fractions of the factory hashrate.
+	DegradedHashrate float64
+	FailedHashrate   float64
+	DegradedChipTemp Celsius
+	FailedChipTemp   Celsius
+}
+
+// DefaultHealthLimits are the limits used by Hashboard.Health.
+var DefaultHealthLimits = HealthLimits{
+	DegradedHashrate: 0.9,
+	FailedHashrate:   0.5,
+	DegradedChipTemp: 95,
+	FailedChipTemp:   105,
+}
+
+// Health assesses b with DefaultHealthLimits.
+func (b *Hashboard) Health() BoardHealth {
+	return b.Assess(DefaultHealthLimits)
+}
+
+// Assess rates b against limits. A board the firmware disabled or does not report alive, or
+// without effective chips, has failed; one whose effective chip count is not reported is
+// degraded. The hashrate is compared with the factory hashrate when the board reports one. A
+// stopped miner therefore shows its boards as failed; check the miner's state first.
+func (b *Hashboard) Assess(limits HealthLimits) BoardHealth {
+	var h BoardHealth
+	report := func(status BoardStatus, format string, args ...any) {
+		h.Status = max(h.Status, status)
+		h.Reasons = append(h.Reasons, fmt.Sprintf(format, args...))
+	}
+
+	if !b.Enabled {
+		report(BoardFailed, "board is disabled")
+	}
+	if b.Status != "" && !strings.EqualFold(b.Status, "Alive") {
+		report(BoardFailed, "board status is %s", b.Status)
+	}
+	switch {
+	case !b.EffectiveChips.Valid():
+		report(BoardDegraded, "effective chip count not reported")
+	case b.EffectiveChips == 0:
+		report(BoardFailed, "no effective chips")
+	}
+
+	if factory := float64(b.FactoryHashrate); factory > 0 && Float(b.Hashrate).Valid() {
+		ratio := float64(b.Hashrate) / factory
+		switch {
+		case ratio < limits.FailedHashrate:
+			report(BoardFailed, "hashrate %v is %.0f%% of factory %v", b.Hashrate, ratio*100, b.FactoryHashrate)
+		case ratio < limits.DegradedHashrate:
+			report(BoardDegraded, "hashrate %v is %.0f%% of factory %v", b.Hashrate, ratio*100, b.FactoryHashrate)
+		}
+	} else if !Float(b.Hashrate).Valid() {
+		report(BoardDegraded, "no hashrate reading")
+	}
+
+	switch temp := b.ChipTempMax; {
+	case !Float(temp).Valid():
+		report(BoardDegraded, "no chip temperature reading")
+	case temp >= limits.FailedChipTemp:
+		report(BoardFailed, "chip temperature %v reaches %v", temp, limits.FailedChipTemp)
+	case temp >= limits.DegradedChipTemp:
+		report(BoardDegraded, "chip temperature %v reaches %v", temp, limits.DegradedChipTemp)
+	}
+	return h
+}
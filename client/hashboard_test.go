@@ -0,0 +1,47 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHashboardUnreportedValues(t *testing.T) {
+	tests := []struct {
+		name         string
+		edev         string
+		wantHashrate Hashrate
+		wantStatus   BoardStatus
+		wantReason   string
+	}{
+		{
+			name:         "stopped board",
+			edev:         `{"Status":"Alive","MHS av":61000000,"MHS 1m":0,"Effective Chips":0,"Chip Temp Max":70}`,
+			wantHashrate: 0,
+			wantStatus:   BoardFailed,
+			wantReason:   "no effective chips",
+		},
+		{
+			name:         "API 3.x board",
+			edev:         `{"Status":"Alive","MHS av":61000000,"Chip Temp Max":70}`,
+			wantHashrate: 61,
+			wantStatus:   BoardDegraded,
+			wantReason:   "effective chip count not reported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e Edev
+			if err := json.Unmarshal([]byte(tt.edev), &e); err != nil {
+				t.Fatal(err)
+			}
+			b := newHashboard(e)
+			if b.Hashrate != tt.wantHashrate {
+				t.Errorf("Hashrate = %v, want %v", b.Hashrate, tt.wantHashrate)
+			}
+			h := b.Health()
+			if h.Status != tt.wantStatus || len(h.Reasons) != 1 || h.Reasons[0] != tt.wantReason {
+				t.Errorf("Health() = %v %q, want %v [%q]", h.Status, h.Reasons, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+)
+
+// DownloadLogs asks the miner for its log bundle with download_logs and streams it to dst. The
+// bundle is a gzip-compressed tarball of /var/log; ParseLogBundle indexes it.
+//
+// It returns the number of bytes written to dst. Only API 2.x miners support downloads; others
+// fail with transport.ErrUnsupported.
+func (w *WriteAPI) DownloadLogs(ctx context.Context, dst io.Writer) (int64, error) {
+	downloader, ok := w.protocol().(transport.Downloader)
+	if !ok {
+		return 0, fmt.Errorf("%w: log download", transport.ErrUnsupported)
+	}
+	if err := w.Capabilities.check(CmdDownloadLogs); err != nil {
+		return 0, err
+	}
+	if dst == nil {
+		return 0, fmt.Errorf("%w: destination must not be nil", ErrInvalidArgument)
+	}
+
+	n, err := downloader.ExecCommandDownload(ctx, w.Token, CmdDownloadLogs, nil, dst)
+	if err != nil {
+		return n, fmt.Errorf("log download failed: %w", err)
+	}
+	return n, nil
+}
+
+// LogSource identifies which daemon wrote a log line.
+type LogSource string
+
+const (
+	LogBTMiner LogSource = "btminer"
+	LogKernel  LogSource = "kernel"
+)
+
+// LogEntry is a single timestamped log line. Lines without a timestamp of their own, such as
+// stack traces, are appended to the preceding entry.
+type LogEntry struct {
+	Time   time.Time
+	Source LogSource
+	File   string // path inside the bundle
+	Text   string // the line without its timestamp
+}
+
+// LogBundle is an extracted log bundle.
+type LogBundle struct {
+	// Files holds every regular file in the bundle by path. Rotated logs compressed with gzip are
+	// stored decompressed, without their .gz suffix.
+	Files map[string][]byte
+	// Entries holds the btminer and kernel log lines of all files, sorted by time.
+	Entries []LogEntry
+}
+
+// maxLogBundleSize bounds the uncompressed size of a bundle accepted by ParseLogBundle.
+const maxLogBundleSize = 256 << 20
+
+// ParseLogBundle extracts a bundle written by DownloadLogs and indexes the btminer and kernel logs
+// by time. Timestamps are taken as UTC because the bundle does not record the miner's time zone;
+// syslog timestamps, which carry no year, are dated no later than the file's modification time.
+func ParseLogBundle(r io.Reader) (*LogBundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log bundle: %w", err)
+	}
+	defer gz.Close()
+
+	bundle := &LogBundle{Files: make(map[string][]byte)}
+	budget := int64(maxLogBundleSize)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := strings.TrimPrefix(path.Clean(hdr.Name), "/")
+		data, err := readLimited(tr, &budget)
+		if err == nil && strings.HasSuffix(name, ".gz") {
+			name = strings.TrimSuffix(name, ".gz")
+			data, err = gunzip(data, &budget)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s from log bundle: %w", hdr.Name, err)
+		}
+		bundle.Files[name] = data
+
+		if source, ok := logSource(name); ok {
+			bundle.Entries = append(bundle.Entries, indexLog(name, source, data, hdr.ModTime)...)
+		}
+	}
+
+	slices.SortStableFunc(bundle.Entries, func(a, b LogEntry) int { return a.Time.Compare(b.Time) })
+	return bundle, nil
+}
+
+// Between returns the entries logged at or after from and before to.
+func (b *LogBundle) Between(from, to time.Time) []LogEntry {
+	start, _ := slices.BinarySearchFunc(b.Entries, from, func(e LogEntry, t time.Time) int { return e.Time.Compare(t) })
+	end, _ := slices.BinarySearchFunc(b.Entries, to, func(e LogEntry, t time.Time) int { return e.Time.Compare(t) })
+	return b.Entries[start:max(start, end)]
+}
+
+// Source returns the entries written by source, in time order.
+func (b *LogBundle) Source(source LogSource) []LogEntry {
+	var entries []LogEntry
+	for _, e := range b.Entries {
+		if e.Source == source {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+var errLogBundleTooLarge = errors.New("log bundle exceeds size limit")
+
+// readLimited reads r completely, charging the bytes read to budget.
+func readLimited(r io.Reader, budget *int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, *budget+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > *budget {
+		return nil, errLogBundleTooLarge
+	}
+	*budget -= int64(len(data))
+	return data, nil
+}
+
+// gunzip decompresses a rotated log, charging its uncompressed size to budget instead of its
+// compressed size.
+func gunzip(data []byte, budget *int64) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	*budget += int64(len(data))
+	return readLimited(gz, budget)
+}
+
+// logSource classifies a file of the bundle by its name.
+func logSource(name string) (LogSource, bool) {
+	base := path.Base(name)
+	switch {
+	case strings.Contains(base, "btminer"):
+		return LogBTMiner, true
+	case strings.Contains(base, "kern"), strings.Contains(base, "dmesg"), strings.HasPrefix(base, "messages"):
+		return LogKernel, true
+	}
+	return "", false
+}
+
+// indexLog splits a log file into entries. Lines before the first timestamp are dropped.
+func indexLog(name string, source LogSource, data []byte, modTime time.Time) []LogEntry {
+	var entries []LogEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if t, text, ok := parseLogTime(line, modTime); ok {
+			entries = append(entries, LogEntry{Time: t, Source: source, File: name, Text: text})
+		} else if n := len(entries); n > 0 && line != "" {
+			entries[n-1].Text += "\n" + line
+		}
+	}
+	return entries
+}
+
+// syslogLayout is the timestamp of syslog lines such as "Jan  2 15:04:05 host kernel: ...".
+const syslogLayout = "Jan _2 15:04:05"
+
+// parseLogTime splits a leading timestamp off line. It understands "2006-01-02 15:04:05" with
+// optional fractional seconds, the same with slashes or a T separator, and syslogLayout. Syslog
+// timestamps get the year of modTime, or the year before if that would put them after modTime.
+func parseLogTime(line string, modTime time.Time) (time.Time, string, bool) {
+	if len(line) >= 19 && line[4] == line[7] && (line[4] == '-' || line[4] == '/') {
+		end := 19
+		for end < len(line) && (line[end] == '.' || line[end] == ',' || line[end] >= '0' && line[end] <= '9') {
+			end++
+		}
+		stamp := strings.NewReplacer("/", "-", "T", " ", ",", ".").Replace(line[:end])
+		if t, err := time.Parse("2006-01-02 15:04:05.999999999", stamp); err == nil {
+			return t, strings.TrimSpace(line[end:]), true
+		}
+	}
+
+	if len(line) >= len(syslogLayout) {
+		if t, err := time.Parse(syslogLayout, line[:len(syslogLayout)]); err == nil {
+			t = t.AddDate(modTime.Year(), 0, 0)
+			if t.After(modTime.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			return t, strings.TrimSpace(line[len(syslogLayout):]), true
+		}
+	}
+	return time.Time{}, "", false
+}
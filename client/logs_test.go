@@ -0,0 +1,163 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogTime(t *testing.T) {
+	modTime := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		line     string
+		modTime  time.Time
+		wantTime time.Time
+		wantText string
+		ok       bool
+	}{
+		{"dashes", "2024-03-09 08:15:30 btminer started", modTime, time.Date(2024, time.March, 9, 8, 15, 30, 0, time.UTC), "btminer started", true},
+		{"slashes", "2024/03/09 08:15:30 pool alive", modTime, time.Date(2024, time.March, 9, 8, 15, 30, 0, time.UTC), "pool alive", true},
+		{"T separator", "2024-03-09T08:15:30 fan ok", modTime, time.Date(2024, time.March, 9, 8, 15, 30, 0, time.UTC), "fan ok", true},
+		{"fractional seconds", "2024-03-09 08:15:30.250 temp 70", modTime, time.Date(2024, time.March, 9, 8, 15, 30, 250e6, time.UTC), "temp 70", true},
+		{"comma before fraction", "2024-03-09 08:15:30,5 temp 71", modTime, time.Date(2024, time.March, 9, 8, 15, 30, 500e6, time.UTC), "temp 71", true},
+		{"syslog without year", "Mar  9 08:15:30 miner kernel: usb 1-1", modTime, time.Date(2024, time.March, 9, 8, 15, 30, 0, time.UTC), "miner kernel: usb 1-1", true},
+		{"syslog two-digit day", "Mar 10 11:59:59 miner kernel: eth0 up", modTime, time.Date(2024, time.March, 10, 11, 59, 59, 0, time.UTC), "miner kernel: eth0 up", true},
+		{"syslog from last year", "Dec 31 23:59:59 miner kernel: ok", time.Date(2024, time.January, 1, 0, 5, 0, 0, time.UTC), time.Date(2023, time.December, 31, 23, 59, 59, 0, time.UTC), "miner kernel: ok", true},
+		{"syslog on the first of January", "Jan  1 00:01:00 miner kernel: ok", time.Date(2024, time.January, 1, 0, 5, 0, 0, time.UTC), time.Date(2024, time.January, 1, 0, 1, 0, 0, time.UTC), "miner kernel: ok", true},
+		{"continuation line", "\tat chip 42", modTime, time.Time{}, "", false},
+		{"empty line", "", modTime, time.Time{}, "", false},
+		{"invalid date", "2024-13-40 08:15:30 nonsense", modTime, time.Time{}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, text, ok := parseLogTime(tt.line, tt.modTime)
+			if ok != tt.ok || !got.Equal(tt.wantTime) || text != tt.wantText {
+				t.Errorf("parseLogTime(%q) = %v, %q, %v, want %v, %q, %v", tt.line, got, text, ok, tt.wantTime, tt.wantText, tt.ok)
+			}
+		})
+	}
+}
+
+// logBundle builds a gzip-compressed tarball holding files, all modified at modTime. Files whose
+// name ends in .gz are compressed.
+func logBundle(t *testing.T, modTime time.Time, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		data := []byte(content)
+		if strings.HasSuffix(name, ".gz") {
+			var compressed bytes.Buffer
+			w := gzip.NewWriter(&compressed)
+			w.Write(data)
+			w.Close()
+			data = compressed.Bytes()
+		}
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseLogBundle(t *testing.T) {
+	modTime := time.Date(2024, time.January, 1, 0, 10, 0, 0, time.UTC)
+	data := logBundle(t, modTime, map[string]string{
+		"var/log/btminer.log": "2024-01-01 00:01:00 btminer started\n" +
+			"2024-01-01 00:03:00.500 chip error\n\tat slot 1\n",
+		"var/log/btminer.log.1.gz": "header without time\n2023-12-31 23:58:00 shutting down\n",
+		"var/log/kern.log":         "Dec 31 23:59:00 miner kernel: reboot\nJan  1 00:02:00 miner kernel: eth0 up\n",
+		"var/log/nginx.log":        "2024-01-01 00:00:30 GET /\n",
+	})
+
+	bundle, err := ParseLogBundle(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseLogBundle: %v", err)
+	}
+	if got := string(bundle.Files["var/log/btminer.log.1"]); got != "header without time\n2023-12-31 23:58:00 shutting down\n" {
+		t.Errorf("rotated log = %q, want it decompressed without .gz", got)
+	}
+	if _, ok := bundle.Files["var/log/nginx.log"]; !ok {
+		t.Error("nginx.log missing from Files")
+	}
+
+	want := []struct {
+		source LogSource
+		text   string
+	}{
+		{LogBTMiner, "shutting down"},
+		{LogKernel, "miner kernel: reboot"},
+		{LogBTMiner, "btminer started"},
+		{LogKernel, "miner kernel: eth0 up"},
+		{LogBTMiner, "chip error\n\tat slot 1"},
+	}
+	if len(bundle.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(bundle.Entries), len(want), bundle.Entries)
+	}
+	for i, e := range bundle.Entries {
+		if e.Source != want[i].source || e.Text != want[i].text {
+			t.Errorf("entry %d = %s %q, want %s %q", i, e.Source, e.Text, want[i].source, want[i].text)
+		}
+	}
+	if n := len(bundle.Source(LogKernel)); n != 2 {
+		t.Errorf("Source(LogKernel) returned %d entries, want 2", n)
+	}
+
+	if _, err := ParseLogBundle(bytes.NewReader([]byte("not gzip"))); err == nil {
+		t.Error("ParseLogBundle accepted data that is not gzip")
+	}
+}
+
+func TestLogBundleBetween(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2024, time.January, 1, 0, minute, 0, 0, time.UTC) }
+	bundle := &LogBundle{Entries: []LogEntry{
+		{Time: at(1), Text: "a"},
+		{Time: at(2), Text: "b"},
+		{Time: at(2), Text: "c"},
+		{Time: at(4), Text: "d"},
+	}}
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     string
+	}{
+		{"everything", at(0), at(10), "abcd"},
+		{"from is inclusive", at(2), at(10), "bcd"},
+		{"to is exclusive", at(1), at(2), "a"},
+		{"between entries", at(3), at(4), ""},
+		{"after the last entry", at(5), at(10), ""},
+		{"reversed range", at(4), at(1), ""},
+	}
+	for _, tt := range tests {
+		var got string
+		for _, e := range bundle.Between(tt.from, tt.to) {
+			got += e.Text
+		}
+		if got != tt.want {
+			t.Errorf("%s: Between = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestDownloadLogsNotIdempotent keeps the registry in line with the transport, which never re-sends
+// a download because part of the bundle may already have been written.
+func TestDownloadLogsNotIdempotent(t *testing.T) {
+	if IsIdempotent(CmdDownloadLogs) {
+		t.Errorf("%s is registered as idempotent", CmdDownloadLogs)
+	}
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+)
+
+var (
+	// ErrAddressInUse is returned when another miner already answers at the requested address.
+	ErrAddressInUse = errors.New("address already in use by another miner")
+	// ErrNetworkUnconfirmed is returned by NetworkSetCustomVerify when the miner does not come back
+	// at its new address.
+	ErrNetworkUnconfirmed = errors.New("miner not confirmed at new address")
+)
+
+// CustomNetworkSettings is a static IPv4 configuration for net_config.
+type CustomNetworkSettings struct {
+	// Address is the miner's address together with the subnet it is in, e.g. 192.168.1.20/24.
+	Address netip.Prefix
+	Gateway netip.Addr
+	DNS     netip.Addr
+	// Host is the new hostname; empty keeps the current one.
+	Host string
+}
+
+// Validate reports whether the settings describe a usable static configuration. The miner itself
+// accepts nearly anything and may become unreachable.
+func (c CustomNetworkSettings) Validate() error {
+	addr := c.Address.Addr()
+	switch {
+	case !c.Address.IsValid() || !addr.Is4():
+		return fmt.Errorf("%w: address %v is not an IPv4 prefix", ErrInvalidArgument, c.Address)
+	case c.Address.Bits() < 1 || c.Address.Bits() > 30:
+		return fmt.Errorf("%w: subnet /%d leaves no room for a gateway", ErrInvalidArgument, c.Address.Bits())
+	case addr.IsLoopback() || addr.IsMulticast() || addr.IsUnspecified() || addr.IsLinkLocalUnicast():
+		return fmt.Errorf("%w: %v cannot be assigned to a miner", ErrInvalidArgument, addr)
+	case addr == c.Address.Masked().Addr() || addr == broadcast(c.Address):
+		return fmt.Errorf("%w: %v is the network or broadcast address of %v", ErrInvalidArgument, addr, c.Address.Masked())
+	case !c.Gateway.Is4() || !c.Address.Contains(c.Gateway) || c.Gateway == addr:
+		return fmt.Errorf("%w: gateway %v must be another address in %v", ErrInvalidArgument, c.Gateway, c.Address.Masked())
+	case !c.DNS.Is4():
+		return fmt.Errorf("%w: DNS server %v is not an IPv4 address", ErrInvalidArgument, c.DNS)
+	case c.Host != "" && !validHostname(c.Host):
+		return fmt.Errorf("%w: invalid hostname %q", ErrInvalidArgument, c.Host)
+	}
+	return nil
+}
+
+// params returns the net_config parameters for the settings.
+func (c CustomNetworkSettings) params() map[string]any {
+	params := map[string]any{
+		"ip":   c.Address.Addr().String(),
+		"mask": net.IP(net.CIDRMask(c.Address.Bits(), 32)).String(),
+		"gate": c.Gateway.String(),
+		"dns":  c.DNS.String(),
+	}
+	if c.Host != "" {
+		params["host"] = c.Host
+	}
+	return params
+}
+
+// broadcast returns the last address of p.
+func broadcast(p netip.Prefix) netip.Addr {
+	a := p.Masked().Addr().As4()
+	binary.BigEndian.PutUint32(a[:], binary.BigEndian.Uint32(a[:])|(1<<(32-p.Bits())-1))
+	return netip.AddrFrom4(a)
+}
+
+// validHostname reports whether name is a single RFC 1123 label.
+func validHostname(name string) bool {
+	if len(name) > 63 || strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// networkProbeTimeout bounds the pre-flight check for another miner at the new address.
+const networkProbeTimeout = 3 * time.Second
+
+// NetworkPollInterval is how often NetworkSetCustomVerify looks for the miner at its new address.
+var NetworkPollInterval = 2 * time.Second
+
+// preflightNetwork checks conf against the miner's current get_miner_info before it is applied and
+// returns that info. It refuses an address at which a miner with another MAC already answers.
+func (w *WriteAPI) preflightNetwork(ctx context.Context, conf CustomNetworkSettings) (*MinerInfoResponse, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	current, err := w.reader(w.Token.IPAddress).MinerInfoContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("pre-flight get_miner_info failed: %w", err)
+	}
+
+	addr := conf.Address.Addr().String()
+	if addr == current.Msg.IP || addr == w.Token.IPAddress {
+		return current, nil
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, networkProbeTimeout)
+	defer cancel()
+	other, err := w.reader(addr).MinerInfoContext(probeCtx)
+	if err == nil && !strings.EqualFold(other.Msg.Mac, current.Msg.Mac) {
+		return nil, fmt.Errorf("%w: %s answers with MAC %s", ErrAddressInUse, addr, other.Msg.Mac)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// NetworkSetCustomVerify is like NetworkSetCustomContext but then waits until the miner answers
+// get_miner_info at its new address with the same MAC and the new configuration. ctx bounds the
+// whole change and should allow for the miner's network restart.
+//
+// It returns the get_miner_info response read at the new address. The WriteAPI keeps talking to
+// the old address; connect again to control the miner further.
+func (w *WriteAPI) NetworkSetCustomVerify(ctx context.Context, conf CustomNetworkSettings) (*MinerInfoResponse, error) {
+	before, err := w.preflightNetwork(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Exec[CommandResponse](ctx, w, CmdNetConfig, conf.params()); err != nil {
+		// The miner may drop the connection as it applies the change; only the check below
+		// tells whether it worked. Failures that prove net_config was never applied end here.
+		var connErr *transport.ConnError
+		if !errors.As(err, &connErr) || transport.NotExecuted(err) {
+			return nil, err
+		}
+	}
+
+	addr := conf.Address.Addr().String()
+	r := w.reader(addr)
+	ticker := time.NewTicker(NetworkPollInterval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			if lastErr == nil {
+				lastErr = ctx.Err()
+			}
+			return nil, fmt.Errorf("%w: %s (MAC %s, was %s): %w", ErrNetworkUnconfirmed, addr, before.Msg.Mac, before.Msg.IP, lastErr)
+		case <-ticker.C:
+		}
+
+		after, err := r.MinerInfoContext(ctx)
+		switch {
+		case err != nil:
+			lastErr = err
+		case !strings.EqualFold(after.Msg.Mac, before.Msg.Mac):
+			return nil, fmt.Errorf("%w: %s answers with MAC %s instead of %s", ErrNetworkUnconfirmed, addr, after.Msg.Mac, before.Msg.Mac)
+		case after.Msg.IP != addr || after.Msg.Proto != "static":
+			lastErr = fmt.Errorf("miner reports %s address %s", after.Msg.Proto, after.Msg.IP)
+		default:
+			return after, nil
+		}
+	}
+}
+
+// reader returns a ReadAPI for the miner at ip, reached on the same port and protocol as w.
+func (w *WriteAPI) reader(ip string) *ReadAPI {
+	if ip == w.Token.IPAddress {
+		return &ReadAPI{API: w.API, Token: w.Token, Protocol: w.Protocol}
+	}
+	return &ReadAPI{API: w.API, Token: &transport.WhatsminerAccessToken{IPAddress: ip, Port: w.Token.Port}, Protocol: w.Protocol}
+}
@@ -0,0 +1,196 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+func TestCustomNetworkSettingsValidate(t *testing.T) {
+	valid := client.CustomNetworkSettings{
+		Address: netip.MustParsePrefix("192.168.1.20/24"),
+		Gateway: netip.MustParseAddr("192.168.1.1"),
+		DNS:     netip.MustParseAddr("1.1.1.1"),
+		Host:    "miner-20",
+	}
+	tests := []struct {
+		name   string
+		modify func(c *client.CustomNetworkSettings)
+		valid  bool
+	}{
+		{"valid", func(c *client.CustomNetworkSettings) {}, true},
+		{"keep hostname", func(c *client.CustomNetworkSettings) { c.Host = "" }, true},
+		{"missing address", func(c *client.CustomNetworkSettings) { c.Address = netip.Prefix{} }, false},
+		{"IPv6 address", func(c *client.CustomNetworkSettings) { c.Address = netip.MustParsePrefix("fd00::20/64") }, false},
+		{"/31 subnet", func(c *client.CustomNetworkSettings) { c.Address = netip.MustParsePrefix("192.168.1.20/31") }, false},
+		{"loopback", func(c *client.CustomNetworkSettings) { c.Address = netip.MustParsePrefix("127.0.0.2/8") }, false},
+		{"link-local", func(c *client.CustomNetworkSettings) { c.Address = netip.MustParsePrefix("169.254.1.20/16") }, false},
+		{"network address", func(c *client.CustomNetworkSettings) { c.Address = netip.MustParsePrefix("192.168.1.0/24") }, false},
+		{"broadcast address", func(c *client.CustomNetworkSettings) { c.Address = netip.MustParsePrefix("192.168.1.255/24") }, false},
+		{"gateway outside subnet", func(c *client.CustomNetworkSettings) { c.Gateway = netip.MustParseAddr("192.168.2.1") }, false},
+		{"gateway is the miner", func(c *client.CustomNetworkSettings) { c.Gateway = netip.MustParseAddr("192.168.1.20") }, false},
+		{"missing DNS", func(c *client.CustomNetworkSettings) { c.DNS = netip.Addr{} }, false},
+		{"invalid hostname", func(c *client.CustomNetworkSettings) { c.Host = "miner_20" }, false},
+		{"hostname with leading hyphen", func(c *client.CustomNetworkSettings) { c.Host = "-miner" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := valid
+			tt.modify(&conf)
+			err := conf.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, client.ErrInvalidArgument) {
+				t.Errorf("Validate() = %v, want ErrInvalidArgument", err)
+			}
+		})
+	}
+}
+
+// newAddress is the address the tests move a simulated miner to. Dials to it are routed to
+// whichever simulator the test's route function returns.
+const newAddress = "10.0.0.20"
+
+// startNetworkSim starts a simulated miner with the given MAC.
+func startNetworkSim(t *testing.T, mac string) *wmapisim.Server {
+	t.Helper()
+	state := wmapisim.DefaultState()
+	state.Network.MAC = mac
+	sim := wmapisim.NewServer("admin", state)
+	if err := sim.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sim.Close() })
+	return sim
+}
+
+// networkWriter returns a WriteAPI for sim whose dials are passed through route, which returns
+// the address to dial instead or an error.
+func networkWriter(t *testing.T, ctx context.Context, sim *wmapisim.Server, route func(address string) (string, error)) *client.WriteAPI {
+	t.Helper()
+	dialer := transport.DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		target, err := route(address)
+		if err != nil {
+			return nil, err
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, target)
+	})
+	api := transport.NewWhatsminerAPI(transport.WithDialer(dialer))
+	token, err := api.NewAccessToken(ctx, sim.Host(), sim.Port(), sim.Password())
+	if err != nil {
+		t.Fatalf("NewAccessToken: %v", err)
+	}
+	t.Cleanup(token.Close)
+	return &client.WriteAPI{API: api, Token: token}
+}
+
+func TestNetworkSetCustomVerify(t *testing.T) {
+	defer func(interval time.Duration) { client.NetworkPollInterval = interval }(client.NetworkPollInterval)
+	client.NetworkPollInterval = 10 * time.Millisecond
+
+	conf := client.CustomNetworkSettings{
+		Address: netip.MustParsePrefix(newAddress + "/24"),
+		Gateway: netip.MustParseAddr("10.0.0.1"),
+		DNS:     netip.MustParseAddr("10.0.0.1"),
+	}
+	unreachable := errors.New("no route to host")
+
+	t.Run("miner comes back at the new address", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sim := startNetworkSim(t, "C4:07:00:00:00:01")
+		w := networkWriter(t, ctx, sim, func(address string) (string, error) {
+			if host, _, _ := net.SplitHostPort(address); host == newAddress {
+				if sim.Received(client.CmdNetConfig) == 0 {
+					return "", unreachable
+				}
+				return sim.Addr().String(), nil
+			}
+			return address, nil
+		})
+
+		info, err := w.NetworkSetCustomVerify(ctx, conf)
+		if err != nil {
+			t.Fatalf("NetworkSetCustomVerify: %v", err)
+		}
+		if info.Msg.IP != newAddress || info.Msg.Proto != "static" {
+			t.Errorf("miner reports %s address %s, want static %s", info.Msg.Proto, info.Msg.IP, newAddress)
+		}
+	})
+
+	t.Run("another miner answers at the new address", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sim := startNetworkSim(t, "C4:07:00:00:00:01")
+		other := startNetworkSim(t, "C4:07:00:00:00:02")
+		w := networkWriter(t, ctx, sim, func(address string) (string, error) {
+			if host, _, _ := net.SplitHostPort(address); host == newAddress {
+				return other.Addr().String(), nil
+			}
+			return address, nil
+		})
+
+		if _, err := w.NetworkSetCustomVerify(ctx, conf); !errors.Is(err, client.ErrAddressInUse) {
+			t.Errorf("NetworkSetCustomVerify = %v, want ErrAddressInUse", err)
+		}
+		if got := sim.Received(client.CmdNetConfig); got != 0 {
+			t.Errorf("miner received %d net_config, want 0", got)
+		}
+	})
+
+	t.Run("another miner answers after the change", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sim := startNetworkSim(t, "C4:07:00:00:00:01")
+		other := startNetworkSim(t, "C4:07:00:00:00:02")
+		w := networkWriter(t, ctx, sim, func(address string) (string, error) {
+			if host, _, _ := net.SplitHostPort(address); host == newAddress {
+				if sim.Received(client.CmdNetConfig) == 0 {
+					return "", unreachable
+				}
+				return other.Addr().String(), nil
+			}
+			return address, nil
+		})
+
+		_, err := w.NetworkSetCustomVerify(ctx, conf)
+		if !errors.Is(err, client.ErrNetworkUnconfirmed) || ctx.Err() != nil {
+			t.Errorf("NetworkSetCustomVerify = %v, want ErrNetworkUnconfirmed before ctx ends", err)
+		}
+	})
+
+	t.Run("net_config cannot be sent", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sim := startNetworkSim(t, "C4:07:00:00:00:01")
+		oldAddress := net.JoinHostPort(sim.Host(), strconv.Itoa(sim.Port()))
+		w := networkWriter(t, ctx, sim, func(address string) (string, error) {
+			switch {
+			case address == oldAddress && sim.Received(client.CmdGetMinerInfo) > 0:
+				// The miner is gone once the pre-flight check has read it.
+				return "", unreachable
+			case address == oldAddress:
+				return address, nil
+			}
+			return "", unreachable
+		})
+
+		_, err := w.NetworkSetCustomVerify(ctx, conf)
+		var connErr *transport.ConnError
+		if !errors.As(err, &connErr) || connErr.Op != "dial" || ctx.Err() != nil {
+			t.Errorf("NetworkSetCustomVerify = %v, want the dial error before ctx ends", err)
+		}
+		if got := sim.Received(client.CmdNetConfig); got != 0 {
+			t.Errorf("miner received %d net_config, want 0", got)
+		}
+	})
+}
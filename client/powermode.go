@@ -0,0 +1,67 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PowerMode is the power mode of a miner, named as the firmware reports it. The empty value means
+// the mode was not reported. Modes this package does not know keep the name the firmware used.
+type PowerMode string
+
+const (
+	LowPower    PowerMode = "Low"
+	NormalPower PowerMode = "Normal"
+	HighPower   PowerMode = "High"
+)
+
+func (m PowerMode) String() string {
+	if m == "" {
+		return "Unknown"
+	}
+	return string(m)
+}
+
+// Known reports whether m is LowPower, NormalPower or HighPower.
+func (m PowerMode) Known() bool {
+	_, ok := m.command()
+	return ok
+}
+
+// ParsePowerMode parses the mode names reported by the firmware, ignoring case.
+func ParsePowerMode(s string) (PowerMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return LowPower, nil
+	case "normal":
+		return NormalPower, nil
+	case "high":
+		return HighPower, nil
+	}
+	return "", fmt.Errorf("%w: unknown power mode %q", ErrInvalidArgument, s)
+}
+
+// command returns the write command that switches the miner to m.
+func (m PowerMode) command() (string, bool) {
+	switch m {
+	case LowPower:
+		return CmdSetLowPower, true
+	case NormalPower:
+		return CmdSetNormalPower, true
+	case HighPower:
+		return CmdSetHighPower, true
+	}
+	return "", false
+}
+
+// UnmarshalText decodes a firmware mode name. Known names are normalized to LowPower,
+// NormalPower or HighPower; other names are kept as reported rather than failing the whole
+// response, and can be told apart with Known.
+func (m *PowerMode) UnmarshalText(text []byte) error {
+	mode, err := ParsePowerMode(string(text))
+	if err != nil {
+		mode = PowerMode(text)
+	}
+	*m = mode
+	return nil
+}
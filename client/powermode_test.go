@@ -0,0 +1,51 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPowerModeDecode(t *testing.T) {
+	tests := []struct {
+		reported string
+		want     PowerMode
+		known    bool
+	}{
+		{"Low", LowPower, true},
+		{"normal", NormalPower, true},
+		{" HIGH ", HighPower, true},
+		{"Sleep", "Sleep", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		data := `{"STATUS":"S","Code":131,"Msg":{"btmineroff":"false","power_mode":"` + tt.reported + `"}}`
+		var status StatusResponse
+		if err := json.Unmarshal([]byte(data), &status); err != nil {
+			t.Errorf("status with power_mode %q: %v", tt.reported, err)
+			continue
+		}
+		if got := status.Msg.PowerMode; got != tt.want || got.Known() != tt.known {
+			t.Errorf("power_mode %q = %q (known %v), want %q (known %v)", tt.reported, got, got.Known(), tt.want, tt.known)
+		}
+		var summary SummaryResponse
+		data = `{"STATUS":[{"STATUS":"S"}],"SUMMARY":[{"Power Mode":"` + tt.reported + `"}]}`
+		if err := json.Unmarshal([]byte(data), &summary); err != nil {
+			t.Errorf("summary with Power Mode %q: %v", tt.reported, err)
+			continue
+		}
+		if got := summary.SUMMARY[0].PowerMode; got != tt.want {
+			t.Errorf("Power Mode %q = %q, want %q", tt.reported, got, tt.want)
+		}
+	}
+}
+
+func TestParsePowerModeRejectsUnknown(t *testing.T) {
+	for _, s := range []string{"", "Sleep", "reboot"} {
+		if m, err := ParsePowerMode(s); err == nil {
+			t.Errorf("ParsePowerMode(%q) = %q, want an error", s, m)
+		}
+	}
+	if _, err := (&WriteAPI{}).SwitchPowerMode("reboot"); err == nil {
+		t.Error(`SwitchPowerMode("reboot") succeeded, want an error`)
+	}
+}
@@ -1,158 +1,105 @@
 package client
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"github.com/GridlessCompute/wmapi/transport"
 )
 
 type ReadAPI struct {
 	API   *transport.WhatsminerAPI
 	Token *transport.WhatsminerAccessToken
+	// Protocol, if set, sends the commands instead of API, e.g. the transport.WhatsminerAPIV3
+	// returned by transport.Detect for API 3.x firmware.
+	Protocol transport.Protocol
+}
+
+// protocol returns what the commands are sent through.
+func (r *ReadAPI) protocol() transport.Protocol {
+	if r.Protocol != nil {
+		return r.Protocol
+	}
+	return r.API
 }
 
 // Summary retrieves the miner's summary information
 func (r *ReadAPI) Summary() (*SummaryResponse, error) {
-	data, err := r.API.GetReadOnlyInfo(r.Token, "summary", nil)
-	if err != nil {
-		return nil, err
-	}
-	var summary SummaryResponse
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
-	if err := json.Unmarshal(jsonData, &summary); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal summary: %w", err)
-	}
-	return &summary, nil
+	return r.SummaryContext(context.Background())
+}
+
+// SummaryContext is like Summary but binds the request to ctx.
+func (r *ReadAPI) SummaryContext(ctx context.Context) (*SummaryResponse, error) {
+	return Read[SummaryResponse](ctx, r, CmdSummary, nil)
 }
 
 // Pools retrieves the configured mining pools
 func (r *ReadAPI) Pools() (*PoolsResponse, error) {
-	data, err := r.API.GetReadOnlyInfo(r.Token, "pools", nil)
-	if err != nil {
-		return nil, err
-	}
-	var pools PoolsResponse
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
-	if err := json.Unmarshal(jsonData, &pools); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal pools: %w", err)
-	}
-	return &pools, nil
+	return r.PoolsContext(context.Background())
+}
+
+// PoolsContext is like Pools but binds the request to ctx.
+func (r *ReadAPI) PoolsContext(ctx context.Context) (*PoolsResponse, error) {
+	return Read[PoolsResponse](ctx, r, CmdPools, nil)
 }
 
 func (r *ReadAPI) Edevs() (*EdevsResponse, error) {
-	data, err := r.API.GetReadOnlyInfo(r.Token, "edevs", nil)
-	if err != nil {
-		return nil, err
-	}
-	var edevs EdevsResponse
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
-	if err := json.Unmarshal(jsonData, &edevs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal edevs: %w", err)
-	}
-	return &edevs, nil
+	return r.EdevsContext(context.Background())
+}
+
+// EdevsContext is like Edevs but binds the request to ctx.
+func (r *ReadAPI) EdevsContext(ctx context.Context) (*EdevsResponse, error) {
+	return Read[EdevsResponse](ctx, r, CmdEdevs, nil)
 }
 
 func (r *ReadAPI) DevDetails() (*DevdetailsResponse, error) {
-	data, err := r.API.GetReadOnlyInfo(r.Token, "devdetails", nil)
-	if err != nil {
-		return nil, err
-	}
-	var devdetails DevdetailsResponse
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
-	if err := json.Unmarshal(jsonData, &devdetails); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal devdetails: %w", err)
-	}
-	return &devdetails, nil
+	return r.DevDetailsContext(context.Background())
+}
+
+// DevDetailsContext is like DevDetails but binds the request to ctx.
+func (r *ReadAPI) DevDetailsContext(ctx context.Context) (*DevdetailsResponse, error) {
+	return Read[DevdetailsResponse](ctx, r, CmdDevDetails, nil)
 }
 
 func (r *ReadAPI) PSU() (*PSUResponse, error) {
-	data, err := r.API.GetReadOnlyInfo(r.Token, "get_psu", nil)
-	if err != nil {
-		return nil, err
-	}
-	var psu PSUResponse
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
-	if err := json.Unmarshal(jsonData, &psu); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal psu: %w", err)
-	}
-	return &psu, nil
+	return r.PSUContext(context.Background())
+}
+
+// PSUContext is like PSU but binds the request to ctx.
+func (r *ReadAPI) PSUContext(ctx context.Context) (*PSUResponse, error) {
+	return Read[PSUResponse](ctx, r, CmdGetPSU, nil)
 }
 
 func (r *ReadAPI) Version() (*VersionResponse, error) {
-	data, err := r.API.GetReadOnlyInfo(r.Token, "get_version", nil)
-	if err != nil {
-		return nil, err
-	}
-	var version VersionResponse
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
-	if err := json.Unmarshal(jsonData, &version); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal version: %w", err)
-	}
-	return &version, nil
+	return r.VersionContext(context.Background())
+}
+
+// VersionContext is like Version but binds the request to ctx.
+func (r *ReadAPI) VersionContext(ctx context.Context) (*VersionResponse, error) {
+	return Read[VersionResponse](ctx, r, CmdGetVersion, nil)
 }
 
 func (r *ReadAPI) Status() (*StatusResponse, error) {
-	data, err := r.API.GetReadOnlyInfo(r.Token, "status", nil)
-	if err != nil {
-		return nil, err
-	}
-	var status StatusResponse
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
-	if err := json.Unmarshal(jsonData, &status); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal status: %w", err)
-	}
-	return &status, nil
+	return r.StatusContext(context.Background())
+}
+
+// StatusContext is like Status but binds the request to ctx.
+func (r *ReadAPI) StatusContext(ctx context.Context) (*StatusResponse, error) {
+	return Read[StatusResponse](ctx, r, CmdStatus, nil)
 }
 
 func (r *ReadAPI) MinerInfo() (*MinerInfoResponse, error) {
-	data, err := r.API.GetReadOnlyInfo(r.Token, "get_miner_info", nil)
-	if err != nil {
-		return nil, err
-	}
-	var minerInfo MinerInfoResponse
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
-	if err := json.Unmarshal(jsonData, &minerInfo); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal status: %w", err)
-	}
-	return &minerInfo, nil
+	return r.MinerInfoContext(context.Background())
+}
+
+// MinerInfoContext is like MinerInfo but binds the request to ctx.
+func (r *ReadAPI) MinerInfoContext(ctx context.Context) (*MinerInfoResponse, error) {
+	return Read[MinerInfoResponse](ctx, r, CmdGetMinerInfo, nil)
 }
 
 func (r *ReadAPI) ErrorCode() (*ErrorResponse, error) {
-	data, err := r.API.GetReadOnlyInfo(r.Token, "get_error_code", nil)
-	if err != nil {
-		return nil, err
-	}
-	var errorResponse ErrorResponse
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
-	if err := json.Unmarshal(jsonData, &errorResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal error response: %w", err)
-	}
-	return &errorResponse, nil
+	return r.ErrorCodeContext(context.Background())
+}
+
+// ErrorCodeContext is like ErrorCode but binds the request to ctx.
+func (r *ReadAPI) ErrorCodeContext(ctx context.Context) (*ErrorResponse, error) {
+	return Read[ErrorResponse](ctx, r, CmdGetErrorCode, nil)
 }
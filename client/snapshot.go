@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SnapshotSection is one response of a MinerSnapshot.
+type SnapshotSection[T any] struct {
+	// Response is nil if the read failed.
+	Response *T `json:"response,omitempty"`
+	// Time is when the response arrived or the read failed.
+	Time time.Time `json:"time"`
+	// Error is the message of Err, kept so failures survive JSON encoding.
+	Error string `json:"error,omitempty"`
+	Err   error  `json:"-"`
+}
+
+// MinerSnapshot is the complete state of a miner as read by ReadAPI.Snapshot.
+type MinerSnapshot struct {
+	Address string `json:"address"`
+	// Time is when the snapshot was started.
+	Time       time.Time                           `json:"time"`
+	Summary    SnapshotSection[SummaryResponse]    `json:"summary"`
+	Pools      SnapshotSection[PoolsResponse]      `json:"pools"`
+	Edevs      SnapshotSection[EdevsResponse]      `json:"edevs"`
+	DevDetails SnapshotSection[DevdetailsResponse] `json:"devdetails"`
+	PSU        SnapshotSection[PSUResponse]        `json:"psu"`
+	Version    SnapshotSection[VersionResponse]    `json:"version"`
+	MinerInfo  SnapshotSection[MinerInfoResponse]  `json:"miner_info"`
+	ErrorCode  SnapshotSection[ErrorResponse]      `json:"error_code"`
+}
+
+// Snapshot reads summary, pools, edevs, devdetails, get_psu, get_version, get_miner_info and
+// get_error_code concurrently. The connections are bounded by the transport's per-miner limit, so
+// reads beyond it wait for a free connection rather than overloading the miner.
+//
+// A failed read only fails its own section; Failed lists them. The error is non-nil only when
+// every read failed, in which case it joins their errors.
+func (r *ReadAPI) Snapshot(ctx context.Context) (*MinerSnapshot, error) {
+	s := &MinerSnapshot{Address: r.Token.IPAddress, Time: time.Now()}
+
+	var wg sync.WaitGroup
+	fetchSection(ctx, &wg, &s.Summary, r.SummaryContext)
+	fetchSection(ctx, &wg, &s.Pools, r.PoolsContext)
+	fetchSection(ctx, &wg, &s.Edevs, r.EdevsContext)
+	fetchSection(ctx, &wg, &s.DevDetails, r.DevDetailsContext)
+	fetchSection(ctx, &wg, &s.PSU, r.PSUContext)
+	fetchSection(ctx, &wg, &s.Version, r.VersionContext)
+	fetchSection(ctx, &wg, &s.MinerInfo, r.MinerInfoContext)
+	fetchSection(ctx, &wg, &s.ErrorCode, r.ErrorCodeContext)
+	wg.Wait()
+
+	sections := s.sections()
+	if failed := s.Failed(); len(failed) == len(sections) {
+		errs := make([]error, 0, len(sections))
+		for _, sec := range sections {
+			errs = append(errs, fmt.Errorf("%s: %w", sec.name, sec.err))
+		}
+		return s, fmt.Errorf("snapshot of %s failed: %w", s.Address, errors.Join(errs...))
+	}
+	return s, nil
+}
+
+// Failed returns the names of the sections whose read failed, in field order. The names are the
+// JSON keys of the sections.
+func (s *MinerSnapshot) Failed() []string {
+	var failed []string
+	for _, sec := range s.sections() {
+		if sec.failed {
+			failed = append(failed, sec.name)
+		}
+	}
+	return failed
+}
+
+type sectionStatus struct {
+	name   string
+	failed bool
+	err    error
+}
+
+func (s *MinerSnapshot) sections() []sectionStatus {
+	return []sectionStatus{
+		status("summary", &s.Summary),
+		status("pools", &s.Pools),
+		status("edevs", &s.Edevs),
+		status("devdetails", &s.DevDetails),
+		status("psu", &s.PSU),
+		status("version", &s.Version),
+		status("miner_info", &s.MinerInfo),
+		status("error_code", &s.ErrorCode),
+	}
+}
+
+// status describes sec. A section decoded from JSON has lost Err but still has Error.
+func status[T any](name string, sec *SnapshotSection[T]) sectionStatus {
+	err := sec.Err
+	if err == nil && sec.Error != "" {
+		err = errors.New(sec.Error)
+	}
+	return sectionStatus{name: name, failed: err != nil, err: err}
+}
+
+func fetchSection[T any](ctx context.Context, wg *sync.WaitGroup, sec *SnapshotSection[T], read func(context.Context) (*T, error)) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sec.Response, sec.Err = read(ctx)
+		sec.Time = time.Now()
+		if sec.Err != nil {
+			sec.Response = nil
+			sec.Error = sec.Err.Error()
+		}
+	}()
+}
@@ -0,0 +1,100 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// TestSnapshotPartialFailure fails pools on a simulated miner and checks that only that section
+// fails and that the snapshot survives a JSON round-trip.
+func TestSnapshotPartialFailure(t *testing.T) {
+	sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+	if err := sim.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	sim.InjectFault(wmapisim.Fault{Kind: wmapisim.FaultStatusError, Cmd: client.CmdPools, Code: 14, Msg: "invalid cmd"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r := &client.ReadAPI{API: transport.NewWhatsminerAPI(), Token: &transport.WhatsminerAccessToken{IPAddress: sim.Host(), Port: sim.Port()}}
+
+	snap, err := r.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if failed := snap.Failed(); !slices.Equal(failed, []string{"pools"}) {
+		t.Errorf("Failed() = %v, want [pools]", failed)
+	}
+	var minerErr *transport.MinerError
+	if snap.Pools.Response != nil || !errors.As(snap.Pools.Err, &minerErr) || snap.Pools.Error == "" {
+		t.Errorf("pools section = %+v, want no response and the miner's error", snap.Pools)
+	}
+	if snap.Summary.Response == nil || len(snap.Summary.Response.SUMMARY) == 0 || snap.Summary.Time.IsZero() {
+		t.Errorf("summary section = %+v, want a response", snap.Summary)
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var decoded client.MinerSnapshot
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if failed := decoded.Failed(); !slices.Equal(failed, []string{"pools"}) {
+		t.Errorf("Failed() after a JSON round-trip = %v, want [pools]", failed)
+	}
+	if decoded.Pools.Error != snap.Pools.Error || decoded.Address != snap.Address || !decoded.Time.Equal(snap.Time) {
+		t.Errorf("decoded snapshot = %+v, want %+v", decoded, snap)
+	}
+	if !reflect.DeepEqual(decoded.Version.Response, snap.Version.Response) {
+		t.Errorf("version after a JSON round-trip = %+v, want %+v", decoded.Version.Response, snap.Version.Response)
+	}
+	if got, want := decoded.Summary.Response.SUMMARY[0].TargetFreq, snap.Summary.Response.SUMMARY[0].TargetFreq; got != want {
+		t.Errorf("Target Freq after a JSON round-trip = %v, want %v", got, want)
+	}
+}
+
+// TestSnapshotAllFailed snapshots a miner that is gone and checks that the error names every
+// section.
+func TestSnapshotAllFailed(t *testing.T) {
+	sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+	if err := sim.Start(); err != nil {
+		t.Fatal(err)
+	}
+	token := &transport.WhatsminerAccessToken{IPAddress: sim.Host(), Port: sim.Port()}
+	sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r := &client.ReadAPI{API: transport.NewWhatsminerAPI(), Token: token}
+
+	snap, err := r.Snapshot(ctx)
+	if err == nil {
+		t.Fatal("Snapshot of a miner that is gone succeeded")
+	}
+	failed := snap.Failed()
+	if len(failed) != 8 {
+		t.Errorf("Failed() = %v, want all 8 sections", failed)
+	}
+	for _, name := range failed {
+		if !strings.Contains(err.Error(), name+": ") {
+			t.Errorf("error %q does not name section %s", err, name)
+		}
+	}
+	var connErr *transport.ConnError
+	if !errors.As(err, &connErr) || connErr.Op != "dial" {
+		t.Errorf("Snapshot = %v, want it to wrap the dial errors", err)
+	}
+}
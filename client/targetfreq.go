@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+)
+
+// TargetFreqPollInterval is how often TargetFreqVerify reads summary after the change.
+var TargetFreqPollInterval = 2 * time.Second
+
+// FreqReading is the frequency part of a summary response, in MHz.
+type FreqReading struct {
+	TargetFreq Float
+	FreqAvg    Float
+}
+
+// TargetFreqResult reports a target frequency change made by TargetFreqVerify.
+type TargetFreqResult struct {
+	// Percent is the offset that was set, after clamping.
+	Percent int
+	// Expected is the Target Freq the miner should report for Percent, or NaN if it could not be
+	// derived because status did not report the previous offset. After is then unverified.
+	Expected Float
+	Before   FreqReading
+	After    FreqReading
+}
+
+// TargetFreqVerify is like TargetFreqContext but then polls summary until its Target Freq reflects
+// the new offset. The expected value is derived from the Target Freq and hash_percent read before
+// the change. If hash_percent is unavailable, the expected value, and so whether the offset was
+// already set, cannot be known: summary is read once after TargetFreqPollInterval and returned
+// without waiting, leaving the comparison of Before and After to the caller. freq_avg follows the
+// target gradually as the chips retune, so it is reported but not waited for.
+//
+// If the change is not reflected before ctx ends, the result holds the last reading and the
+// error wraps ErrNotReflected and the last failed read, if any.
+func (w *WriteAPI) TargetFreqVerify(ctx context.Context, tgt int) (*TargetFreqResult, error) {
+	r := &ReadAPI{API: w.API, Token: w.Token, Protocol: w.Protocol}
+	res := &TargetFreqResult{Percent: clampPercent(tgt), Expected: Float(math.NaN())}
+
+	before, err := readFreq(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read frequency before the change: %w", err)
+	}
+	res.Before = before
+	if status, err := r.StatusContext(ctx); err == nil {
+		if pct, err := strconv.Atoi(status.Msg.HashPercent); err == nil && pct > 0 {
+			res.Expected = before.TargetFreq * Float(100+res.Percent) / Float(pct)
+		}
+	}
+
+	if _, err := w.TargetFreqContext(ctx, res.Percent); err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(TargetFreqPollInterval)
+	defer ticker.Stop()
+	if !res.Expected.Valid() {
+		select {
+		case <-ctx.Done():
+			return res, ctx.Err()
+		case <-ticker.C:
+		}
+		if res.After, err = readFreq(ctx, r); err != nil {
+			return res, fmt.Errorf("failed to read frequency after the change: %w", err)
+		}
+		return res, nil
+	}
+
+	var readErr error
+	for {
+		after, err := readFreq(ctx, r)
+		if err == nil {
+			res.After = after
+			if res.reflected() {
+				return res, nil
+			}
+		}
+		readErr = err
+
+		select {
+		case <-ctx.Done():
+			err := fmt.Errorf("%w: Target Freq is %v MHz, expected %v MHz: %w", ErrNotReflected, res.After.TargetFreq, res.Expected, ctx.Err())
+			if readErr != nil {
+				err = fmt.Errorf("%w (last read: %w)", err, readErr)
+			}
+			return res, err
+		case <-ticker.C:
+		}
+	}
+}
+
+// reflected reports whether After shows the change, allowing 0.5% or 1 MHz for rounding by the
+// firmware.
+func (t *TargetFreqResult) reflected() bool {
+	if !t.After.TargetFreq.Valid() || !t.Expected.Valid() {
+		return false
+	}
+	tolerance := max(1, math.Abs(float64(t.Expected))*0.005)
+	return math.Abs(float64(t.After.TargetFreq-t.Expected)) <= tolerance
+}
+
+func readFreq(ctx context.Context, r *ReadAPI) (FreqReading, error) {
+	summary, err := r.SummaryContext(ctx)
+	if err != nil {
+		return FreqReading{}, err
+	}
+	if len(summary.SUMMARY) == 0 {
+		return FreqReading{}, fmt.Errorf("%w: summary reported no data", transport.ErrInvalidResponse)
+	}
+	s := summary.SUMMARY[0]
+	return FreqReading{TargetFreq: s.TargetFreq, FreqAvg: s.FreqAvg}, nil
+}
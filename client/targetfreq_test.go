@@ -0,0 +1,63 @@
+package client_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// TestTargetFreqVerify changes the offset of a simulated miner that already runs at +10% and
+// checks that the expected Target Freq is derived from the hash_percent nested in status.
+func TestTargetFreqVerify(t *testing.T) {
+	defer func(interval time.Duration) { client.TargetFreqPollInterval = interval }(client.TargetFreqPollInterval)
+	client.TargetFreqPollInterval = 10 * time.Millisecond
+
+	for _, apiVersion := range []string{"2.0.5", "3.0.1"} {
+		t.Run("API "+apiVersion, func(t *testing.T) {
+			state := wmapisim.DefaultState()
+			state.APIVersion = apiVersion
+			state.TargetFreqPercent = 10
+			sim := wmapisim.NewServer("admin", state)
+			if err := sim.Start(); err != nil {
+				t.Fatal(err)
+			}
+			if err := sim.StartV3(); err != nil {
+				t.Fatal(err)
+			}
+			defer sim.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			proto, port, _, err := transport.Detect(ctx, sim.Host(), sim.Port(), transport.WithV3Port(sim.V3Port()))
+			if err != nil {
+				t.Fatalf("Detect: %v", err)
+			}
+			token, err := proto.NewAccessToken(ctx, sim.Host(), port, sim.Password())
+			if err != nil {
+				t.Fatalf("NewAccessToken: %v", err)
+			}
+			defer token.Close()
+			w := &client.WriteAPI{API: transport.NewWhatsminerAPI(), Token: token, Protocol: proto}
+
+			res, err := w.TargetFreqVerify(ctx, -10)
+			if err != nil {
+				t.Fatalf("TargetFreqVerify: %v", err)
+			}
+			want := float64(res.Before.TargetFreq) * 90 / 110
+			if !res.Expected.Valid() || math.Abs(float64(res.Expected)-want) > 0.01 {
+				t.Errorf("Expected = %v, want %v", res.Expected, want)
+			}
+			if math.Abs(float64(res.After.TargetFreq)-want) > 1 {
+				t.Errorf("After.TargetFreq = %v, want %v", res.After.TargetFreq, want)
+			}
+			if got := sim.State().TargetFreqPercent; got != -10 {
+				t.Errorf("simulator offset = %d, want -10", got)
+			}
+		})
+	}
+}
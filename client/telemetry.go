@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/GridlessCompute/wmapi/transport"
+)
+
+// Telemetry is the state of a miner in explicit units, normalized from summary, edevs and get_psu.
+// Values the miner did not report are NaN.
+type Telemetry struct {
+	// Hashrate is the one-minute average.
+	Hashrate Hashrate `json:"hashrate_ths"`
+	// HashrateAvg is the average since mining started.
+	HashrateAvg     Hashrate   `json:"hashrate_avg_ths"`
+	TargetHashrate  Hashrate   `json:"target_hashrate_ths"`
+	FactoryHashrate Hashrate   `json:"factory_hashrate_ths"`
+	Power           Watts      `json:"power_w"`
+	PowerLimit      Watts      `json:"power_limit_w"`
+	Efficiency      Efficiency `json:"efficiency_jth"`
+	// Temperature is the average board temperature.
+	Temperature Celsius `json:"temperature_c"`
+	EnvTemp     Celsius `json:"env_temp_c"`
+	ChipTempMin Celsius `json:"chip_temp_min_c"`
+	ChipTempMax Celsius `json:"chip_temp_max_c"`
+	ChipTempAvg Celsius `json:"chip_temp_avg_c"`
+	FanIn       RPM     `json:"fan_in_rpm"`
+	FanOut      RPM     `json:"fan_out_rpm"`
+	// FreqAvg is the average chip frequency in MHz.
+	FreqAvg Float        `json:"freq_avg_mhz"`
+	PSU     PSUTelemetry `json:"psu"`
+	// Boards lacks the devdetails values; ReadAPI.Hashboards has them.
+	Boards []Hashboard `json:"boards"`
+}
+
+// PSUTelemetry is the power supply part of Telemetry.
+type PSUTelemetry struct {
+	InputPower Watts `json:"input_power_w"`
+	// InputVoltage is in V and InputCurrent in A.
+	InputVoltage Float   `json:"input_voltage_v"`
+	InputCurrent Float   `json:"input_current_a"`
+	Temperature  Celsius `json:"temperature_c"`
+	Fan          RPM     `json:"fan_rpm"`
+}
+
+// Telemetry reads summary, edevs and get_psu and normalizes them with NewTelemetry.
+func (r *ReadAPI) Telemetry() (*Telemetry, error) {
+	return r.TelemetryContext(context.Background())
+}
+
+// TelemetryContext is like Telemetry but binds the requests to ctx. Firmware without get_psu
+// leaves the PSU values NaN.
+func (r *ReadAPI) TelemetryContext(ctx context.Context) (*Telemetry, error) {
+	summary, err := r.SummaryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	edevs, err := r.EdevsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	psu, err := r.PSUContext(ctx)
+	if errors.Is(err, transport.ErrUnsupported) {
+		psu, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewTelemetry(summary, edevs, psu)
+}
+
+// NewTelemetry normalizes the given responses. summary is required; edevs and psu may be nil, in
+// which case Boards is empty and the PSU values are NaN.
+//
+// Efficiency is the miner's Power Rate, or derived from Power and Hashrate if the firmware did not
+// report one.
+func NewTelemetry(summary *SummaryResponse, edevs *EdevsResponse, psu *PSUResponse) (*Telemetry, error) {
+	if summary == nil || len(summary.SUMMARY) == 0 {
+		return nil, fmt.Errorf("%w: summary reported no data", transport.ErrInvalidResponse)
+	}
+	s := summary.SUMMARY[0]
+
+	t := &Telemetry{
+		Hashrate:        HashrateFromMHS(s.MHS1M),
+		HashrateAvg:     HashrateFromMHS(s.MHSAv),
+		TargetHashrate:  HashrateFromMHS(s.TargetMHS),
+		FactoryHashrate: HashrateFromGHS(s.FactoryGHS),
+		Power:           Watts(s.Power),
+		PowerLimit:      Watts(s.PowerLimit),
+		Efficiency:      Efficiency(s.PowerRate),
+		Temperature:     Celsius(s.Temperature),
+		EnvTemp:         Celsius(s.EnvTemp),
+		ChipTempMin:     Celsius(s.ChipTempMin),
+		ChipTempMax:     Celsius(s.ChipTempMax),
+		ChipTempAvg:     Celsius(s.ChipTempAvg),
+		FanIn:           RPM(s.FanSpeedIn),
+		FanOut:          RPM(s.FanSpeedOut),
+		FreqAvg:         s.FreqAvg,
+	}
+	if !(t.Efficiency > 0) || !Float(t.Efficiency).Valid() {
+		t.Efficiency = EfficiencyOf(t.Power, t.Hashrate)
+	}
+
+	nan := Float(math.NaN())
+	t.PSU = PSUTelemetry{InputPower: Watts(nan), InputVoltage: nan, InputCurrent: nan, Temperature: Celsius(nan), Fan: RPM(nan)}
+	if psu != nil {
+		t.PSU = PSUTelemetry{
+			InputPower:   Watts(parseFloat(psu.Msg.Pin)),
+			InputVoltage: parseFloat(psu.Msg.Vin),
+			InputCurrent: parseFloat(psu.Msg.Iin),
+			Temperature:  Celsius(parseFloat(psu.Msg.Temp0)),
+			Fan:          RPM(parseFloat(psu.Msg.FanSpeed)),
+		}
+	}
+
+	t.Boards = MergeHashboards(edevs, nil)
+	return t, nil
+}
@@ -0,0 +1,98 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+func TestNewTelemetry(t *testing.T) {
+	nan, inf := client.Float(math.NaN()), client.Float(math.Inf(1))
+	summary := func(mhs, power, rate client.Float) *client.SummaryResponse {
+		return &client.SummaryResponse{SUMMARY: []client.SummaryInfo{{
+			MHS1M: mhs, MHSAv: mhs, TargetMHS: mhs, FactoryGHS: 110_000,
+			Power: power, PowerRate: rate, Temperature: 65, FanSpeedIn: 4800,
+		}}}
+	}
+	psu := &client.PSUResponse{Msg: client.PSUInfo{Pin: "3410", Vin: "230.5", Iin: "14.8", Temp0: "41.0", FanSpeed: "6720"}}
+
+	tests := []struct {
+		name           string
+		summary        *client.SummaryResponse
+		psu            *client.PSUResponse
+		wantEfficiency float64
+		wantPSUPower   float64
+	}{
+		{"reported Power Rate", summary(110e6, 3300, 29.5), psu, 29.5, 3410},
+		{"NaN Power Rate", summary(110e6, 3300, nan), psu, 30, 3410},
+		{"Inf Power Rate", summary(110e6, 3300, inf), psu, 30, 3410},
+		{"zero Power Rate", summary(110e6, 3300, 0), psu, 30, 3410},
+		{"no Power Rate and no power", summary(110e6, nan, nan), psu, math.NaN(), 3410},
+		{"no Power Rate and no hash rate", summary(0, 3300, inf), psu, math.NaN(), 3410},
+		{"no get_psu", summary(110e6, 3300, 29.5), nil, 29.5, math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tel, err := client.NewTelemetry(tt.summary, nil, tt.psu)
+			if err != nil {
+				t.Fatalf("NewTelemetry: %v", err)
+			}
+			if !sameFloat(float64(tel.Efficiency), tt.wantEfficiency) {
+				t.Errorf("Efficiency = %v, want %v", tel.Efficiency, tt.wantEfficiency)
+			}
+			if !sameFloat(float64(tel.PSU.InputPower), tt.wantPSUPower) {
+				t.Errorf("PSU.InputPower = %v, want %v", tel.PSU.InputPower, tt.wantPSUPower)
+			}
+			if tel.Hashrate.TH() != float64(tt.summary.SUMMARY[0].MHS1M)/1e6 || tel.FactoryHashrate.TH() != 110 {
+				t.Errorf("Hashrate = %v, FactoryHashrate = %v, want them in TH/s", tel.Hashrate, tel.FactoryHashrate)
+			}
+			if tel.Temperature != 65 || tel.FanIn != 4800 || len(tel.Boards) != 0 {
+				t.Errorf("Telemetry = %+v, want the summary values and no boards", tel)
+			}
+		})
+	}
+
+	for _, s := range []*client.SummaryResponse{nil, {}} {
+		if _, err := client.NewTelemetry(s, nil, psu); !errors.Is(err, transport.ErrInvalidResponse) {
+			t.Errorf("NewTelemetry(%v) = %v, want ErrInvalidResponse", s, err)
+		}
+	}
+}
+
+// TestTelemetryNonStandardNumbers reads telemetry from a miner that reports its Power Rate as inf
+// and checks that the efficiency is derived from power and hash rate instead.
+func TestTelemetryNonStandardNumbers(t *testing.T) {
+	sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+	if err := sim.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	sim.InjectFault(wmapisim.Fault{Kind: wmapisim.FaultNonStandardNumbers, Cmd: client.CmdSummary})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r := &client.ReadAPI{API: transport.NewWhatsminerAPI(), Token: &transport.WhatsminerAccessToken{IPAddress: sim.Host(), Port: sim.Port()}}
+
+	tel, err := r.TelemetryContext(ctx)
+	if err != nil {
+		t.Fatalf("TelemetryContext: %v", err)
+	}
+	want := float64(tel.Power) / tel.Hashrate.TH()
+	if !client.Float(tel.Efficiency).Valid() || math.Abs(float64(tel.Efficiency)-want) > 1e-9 {
+		t.Errorf("Efficiency = %v, want Power / Hashrate = %v", tel.Efficiency, want)
+	}
+}
+
+// sameFloat reports whether got equals want, treating two NaNs as equal.
+func sameFloat(got, want float64) bool {
+	if math.IsNaN(want) {
+		return math.IsNaN(got)
+	}
+	return math.Abs(got-want) < 1e-9
+}
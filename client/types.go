@@ -7,61 +7,76 @@ type Pool struct {
 }
 
 type CommandResponse struct {
-	STATUS      string  `json:"STATUS"`
-	When        float64 `json:"When"`
-	Code        float64 `json:"Code"`
-	Msg         any     `json:"Msg"`
-	Description string  `json:"Description"`
+	STATUS      string `json:"STATUS"`
+	When        Float  `json:"When"`
+	Code        Float  `json:"Code"`
+	Msg         any    `json:"Msg"`
+	Description string `json:"Description"`
 }
 
 type ErrorResponse struct {
-	STATUS      string  `json:"STATUS"`
-	When        float64 `json:"When"`
-	Code        float64 `json:"Code"`
-	Msg         any     `json:"Msg"`
-	Description string  `json:"Description"`
+	STATUS      string        `json:"STATUS"`
+	When        Float         `json:"When"`
+	Code        Float         `json:"Code"`
+	Msg         ErrorCodeList `json:"Msg"`
+	Description string        `json:"Description"`
 }
 
 type StatusResponse struct {
-	Btmineroff      string `json:"btmineroff"`
-	FirmwareVersion string `json:"Firmware Version"`
-	PowerMode       string `json:"power_mode"`
-	PowerLimitSet   string `json:"power_limit_set"`
-	HashPercent     string `json:"hash_percent"`
+	STATUS      string     `json:"STATUS"`
+	When        Float      `json:"When"`
+	Code        Float      `json:"Code"`
+	Msg         StatusInfo `json:"Msg"`
+	Description string     `json:"Description"`
+}
+
+// StatusInfo is the Msg of a status response.
+type StatusInfo struct {
+	Btmineroff      string    `json:"btmineroff"`
+	FirmwareVersion string    `json:"Firmware Version"`
+	PowerMode       PowerMode `json:"power_mode"`
+	PowerLimitSet   string    `json:"power_limit_set"`
+	HashPercent     string    `json:"hash_percent"`
 }
 
 type VersionResponse struct {
-	STATUS string  `json:"STATUS"`
-	When   float64 `json:"When"`
-	Code   float64 `json:"Code"`
-	Msg    struct {
-		APIVer   string `json:"api_ver"`
-		FwVer    string `json:"fw_ver"`
-		Platform string `json:"platform"`
-		Chip     string `json:"chip"`
-	} `json:"Msg"`
-	Description string `json:"Description"`
+	STATUS      string      `json:"STATUS"`
+	When        Float       `json:"When"`
+	Code        Float       `json:"Code"`
+	Msg         VersionInfo `json:"Msg"`
+	Description string      `json:"Description"`
+}
+
+// VersionInfo is the Msg of a get_version response.
+type VersionInfo struct {
+	APIVer   string `json:"api_ver"`
+	FwVer    string `json:"fw_ver"`
+	Platform string `json:"platform"`
+	Chip     string `json:"chip"`
 }
 
 type PSUResponse struct {
-	STATUS string  `json:"STATUS"`
-	When   float64 `json:"When"`
-	Code   float64 `json:"Code"`
-	Msg    struct {
-		Name      string `json:"name"`
-		HwVersion string `json:"hw_version"`
-		SwVersion string `json:"sw_version"`
-		Model     string `json:"model"`
-		Iin       string `json:"iin"`
-		Vin       string `json:"vin"`
-		Pin       string `json:"pin"`
-		FanSpeed  string `json:"fan_speed"`
-		Version   string `json:"version"`
-		SerialNo  string `json:"serial_no"`
-		Vendor    string `json:"vendor"`
-		Temp0     string `json:"temp0"`
-	} `json:"Msg"`
-	Description string `json:"Description"`
+	STATUS      string  `json:"STATUS"`
+	When        Float   `json:"When"`
+	Code        Float   `json:"Code"`
+	Msg         PSUInfo `json:"Msg"`
+	Description string  `json:"Description"`
+}
+
+// PSUInfo is the Msg of a get_psu response. The readings are strings.
+type PSUInfo struct {
+	Name      string `json:"name"`
+	HwVersion string `json:"hw_version"`
+	SwVersion string `json:"sw_version"`
+	Model     string `json:"model"`
+	Iin       string `json:"iin"`
+	Vin       string `json:"vin"`
+	Pin       string `json:"pin"`
+	FanSpeed  string `json:"fan_speed"`
+	Version   string `json:"version"`
+	SerialNo  string `json:"serial_no"`
+	Vendor    string `json:"vendor"`
+	Temp0     string `json:"temp0"`
 }
 
 type DevdetailsResponse struct {
@@ -72,14 +87,17 @@ type DevdetailsResponse struct {
 	// 	Msg         string `json:"Msg"`
 	// 	Description string `json:"Description"`
 	// } `json:"STATUS"`
-	DEVDETAILS []struct {
-		DEVDETAILS float64 `json:"DEVDETAILS"`
-		Name       string  `json:"Name"`
-		ID         float64 `json:"ID"`
-		Driver     string  `json:"Driver"`
-		Kernel     string  `json:"Kernel"`
-		Model      string  `json:"Model"`
-	} `json:"DEVDETAILS"`
+	DEVDETAILS []DevDetail `json:"DEVDETAILS"`
+}
+
+// DevDetail is one hashboard entry of a devdetails response.
+type DevDetail struct {
+	DEVDETAILS Float  `json:"DEVDETAILS"`
+	Name       string `json:"Name"`
+	ID         Float  `json:"ID"`
+	Driver     string `json:"Driver"`
+	Kernel     string `json:"Kernel"`
+	Model      string `json:"Model"`
 }
 
 type EdevsResponse struct {
@@ -87,48 +105,55 @@ type EdevsResponse struct {
 	// 	STATUS string `json:"STATUS"`
 	// 	Msg    string `json:"Msg"`
 	// } `json:"STATUS"`
-	DEVS []struct {
-		ASC            float64 `json:"ASC"`
-		Slot           float64 `json:"Slot"`
-		Enabled        string  `json:"Enabled"`
-		Status         string  `json:"Status"`
-		Temperature    float64 `json:"Temperature"`
-		ChipFrequency  float64 `json:"Chip Frequency"`
-		MHSAv          float64 `json:"MHS av"`
-		MHS5S          float64 `json:"MHS 5s"`
-		MHS1M          float64 `json:"MHS 1m"`
-		MHS5M          float64 `json:"MHS 5m"`
-		MHS15M         float64 `json:"MHS 15m"`
-		HSRT           float64 `json:"HS RT"`
-		HSFactory      float64 `json:"HS Factory,omitempty"`
-		Accepted       float64 `json:"Accepted"`
-		Rejected       float64 `json:"Rejected"`
-		LastValidWork  float64 `json:"Last Valid Work"`
-		UpfreqComplete float64 `json:"Upfreq Complete"`
-		EffectiveChips float64 `json:"Effective Chips"`
-		PCBSN          string  `json:"PCB SN"`
-		ChipData       string  `json:"Chip Data"`
-		ChipTempMin    float64 `json:"Chip Temp Min"`
-		ChipTempMax    float64 `json:"Chip Temp Max"`
-		ChipTempAvg    float64 `json:"Chip Temp Avg"`
-		ChipVolDiff    float64 `json:"chip_vol_diff"`
-	} `json:"DEVS"`
+	DEVS []Edev `json:"DEVS"`
+}
+
+// Edev is one hashboard entry of an edevs response.
+type Edev struct {
+	ASC            Float  `json:"ASC"`
+	Slot           Float  `json:"Slot"`
+	Enabled        string `json:"Enabled"`
+	Status         string `json:"Status"`
+	Temperature    Float  `json:"Temperature"`
+	ChipFrequency  Float  `json:"Chip Frequency"`
+	MHSAv          Float  `json:"MHS av"`
+	MHS5S          Float  `json:"MHS 5s"`
+	MHS1M          Float  `json:"MHS 1m"`
+	MHS5M          Float  `json:"MHS 5m"`
+	MHS15M         Float  `json:"MHS 15m"`
+	HSRT           Float  `json:"HS RT"`
+	HSFactory      Float  `json:"HS Factory,omitempty"`
+	Accepted       Float  `json:"Accepted"`
+	Rejected       Float  `json:"Rejected"`
+	LastValidWork  Float  `json:"Last Valid Work"`
+	UpfreqComplete Float  `json:"Upfreq Complete"`
+	EffectiveChips Float  `json:"Effective Chips"`
+	PCBSN          string `json:"PCB SN"`
+	ChipData       string `json:"Chip Data"`
+	ChipTempMin    Float  `json:"Chip Temp Min"`
+	ChipTempMax    Float  `json:"Chip Temp Max"`
+	ChipTempAvg    Float  `json:"Chip Temp Avg"`
+	ChipVolDiff    Float  `json:"chip_vol_diff"`
 }
 
 type MinerInfoResponse struct {
-	STATUS string  `json:"STATUS"`
-	When   float64 `json:"When"`
-	Code   float64 `json:"Code"`
-	Msg    struct {
-		IP      string `json:"ip"`
-		Proto   string `json:"proto"`
-		Netmask string `json:"netmask"`
-		DNS     string `json:"dns"`
-		Mac     string `json:"mac"`
-		Ledstat string `json:"ledstat"`
-		Gateway string `json:"gateway"`
-	} `json:"Msg"`
-	Description string `json:"Description"`
+	STATUS      string    `json:"STATUS"`
+	When        Float     `json:"When"`
+	Code        Float     `json:"Code"`
+	Msg         MinerInfo `json:"Msg"`
+	Description string    `json:"Description"`
+}
+
+// MinerInfo is the Msg of a get_miner_info response.
+type MinerInfo struct {
+	IP       string `json:"ip"`
+	Proto    string `json:"proto"`
+	Netmask  string `json:"netmask"`
+	DNS      string `json:"dns"`
+	Mac      string `json:"mac"`
+	Ledstat  string `json:"ledstat"`
+	Gateway  string `json:"gateway"`
+	Hostname string `json:"hostname"`
 }
 
 type PoolsResponse struct {
@@ -136,42 +161,45 @@ type PoolsResponse struct {
 	// 	STATUS string `json:"STATUS"`
 	// 	Msg    string `json:"Msg"`
 	// } `json:"STATUS"`
-	POOLS []struct {
-		POOL                float64 `json:"POOL"`
-		URL                 string  `json:"URL"`
-		Status              string  `json:"Status"`
-		Priority            float64 `json:"Priority"`
-		Quota               float64 `json:"Quota"`
-		LongPoll            string  `json:"Long Poll"`
-		Getworks            float64 `json:"Getworks"`
-		Accepted            float64 `json:"Accepted"`
-		Rejected            float64 `json:"Rejected"`
-		Works               float64 `json:"Works"`
-		Discarded           float64 `json:"Discarded"`
-		Stale               float64 `json:"Stale"`
-		GetFailures         float64 `json:"Get Failures"`
-		RemoteFailures      float64 `json:"Remote Failures"`
-		User                string  `json:"User"`
-		LastShareTime       float64 `json:"Last Share Time"`
-		Diff1Shares         float64 `json:"Diff1 Shares"`
-		ProxyType           string  `json:"Proxy Type"`
-		Proxy               string  `json:"Proxy"`
-		DifficultyAccepted  float64 `json:"Difficulty Accepted"`
-		DifficultyRejected  float64 `json:"Difficulty Rejected"`
-		DifficultyStale     float64 `json:"Difficulty Stale"`
-		LastShareDifficulty float64 `json:"Last Share Difficulty"`
-		WorkDifficulty      float64 `json:"Work Difficulty"`
-		HasStratum          float64 `json:"Has Stratum"`
-		StratumActive       bool    `json:"Stratum Active"`
-		StratumURL          string  `json:"Stratum URL"`
-		StratumDifficulty   float64 `json:"Stratum Difficulty"`
-		BestShare           float64 `json:"Best Share"`
-		PoolRejected        float64 `json:"Pool Rejected%"`
-		PoolStale           float64 `json:"Pool Stale%"`
-		BadWork             float64 `json:"Bad Work"`
-		CurrentBlockHeight  float64 `json:"Current Block Height"`
-		CurrentBlockVersion float64 `json:"Current Block Version"`
-	} `json:"POOLS"`
+	POOLS []PoolInfo `json:"POOLS"`
+}
+
+// PoolInfo is one pool entry of a pools response.
+type PoolInfo struct {
+	POOL                Float  `json:"POOL"`
+	URL                 string `json:"URL"`
+	Status              string `json:"Status"`
+	Priority            Float  `json:"Priority"`
+	Quota               Float  `json:"Quota"`
+	LongPoll            string `json:"Long Poll"`
+	Getworks            Float  `json:"Getworks"`
+	Accepted            Float  `json:"Accepted"`
+	Rejected            Float  `json:"Rejected"`
+	Works               Float  `json:"Works"`
+	Discarded           Float  `json:"Discarded"`
+	Stale               Float  `json:"Stale"`
+	GetFailures         Float  `json:"Get Failures"`
+	RemoteFailures      Float  `json:"Remote Failures"`
+	User                string `json:"User"`
+	LastShareTime       Float  `json:"Last Share Time"`
+	Diff1Shares         Float  `json:"Diff1 Shares"`
+	ProxyType           string `json:"Proxy Type"`
+	Proxy               string `json:"Proxy"`
+	DifficultyAccepted  Float  `json:"Difficulty Accepted"`
+	DifficultyRejected  Float  `json:"Difficulty Rejected"`
+	DifficultyStale     Float  `json:"Difficulty Stale"`
+	LastShareDifficulty Float  `json:"Last Share Difficulty"`
+	WorkDifficulty      Float  `json:"Work Difficulty"`
+	HasStratum          Float  `json:"Has Stratum"`
+	StratumActive       bool   `json:"Stratum Active"`
+	StratumURL          string `json:"Stratum URL"`
+	StratumDifficulty   Float  `json:"Stratum Difficulty"`
+	BestShare           Float  `json:"Best Share"`
+	PoolRejected        Float  `json:"Pool Rejected%"`
+	PoolStale           Float  `json:"Pool Stale%"`
+	BadWork             Float  `json:"Bad Work"`
+	CurrentBlockHeight  Float  `json:"Current Block Height"`
+	CurrentBlockVersion Float  `json:"Current Block Version"`
 }
 
 type SummaryResponse struct {
@@ -179,41 +207,44 @@ type SummaryResponse struct {
 	// 	STATUS string `json:"STATUS"`
 	// 	Msg    string `json:"Msg"`
 	// } `json:"STATUS"`
-	SUMMARY []struct {
-		Elapsed               float64 `json:"Elapsed"`
-		MHSAv                 float64 `json:"MHS av"`
-		MHS5S                 float64 `json:"MHS 5s"`
-		MHS1M                 float64 `json:"MHS 1m"`
-		MHS5M                 float64 `json:"MHS 5m"`
-		MHS15M                float64 `json:"MHS 15m"`
-		HSRT                  float64 `json:"HS RT"`
-		Accepted              float64 `json:"Accepted"`
-		Rejected              float64 `json:"Rejected"`
-		TotalMH               float64 `json:"Total MH"`
-		Temperature           float64 `json:"Temperature"`
-		FreqAvg               float64 `json:"freq_avg"`
-		FanSpeedIn            float64 `json:"Fan Speed In"`
-		FanSpeedOut           float64 `json:"Fan Speed Out"`
-		Power                 float64 `json:"Power"`
-		PowerRate             float64 `json:"Power Rate"`
-		PoolRejected          float64 `json:"Pool Rejected%"`
-		PoolStale             float64 `json:"Pool Stale%"`
-		LastGetwork           float64 `json:"Last getwork"`
-		Uptime                float64 `json:"Uptime"`
-		SecurityMode          float64 `json:"Security Mode"`
-		HashStable            bool    `json:"Hash Stable"`
-		HashStableCostSeconds float64 `json:"Hash Stable Cost Seconds"`
-		HashDeviation         float64 `json:"Hash Deviation%"`
-		TargetFreq            float64 `json:"Target Freq"`
-		TargetMHS             float64 `json:"Target MHS"`
-		EnvTemp               float64 `json:"Env Temp"`
-		PowerMode             string  `json:"Power Mode"`
-		FactoryGHS            float64 `json:"Factory GHS"`
-		PowerLimit            float64 `json:"Power Limit"`
-		ChipTempMin           float64 `json:"Chip Temp Min"`
-		ChipTempMax           float64 `json:"Chip Temp Max"`
-		ChipTempAvg           float64 `json:"Chip Temp Avg"`
-		Debug                 string  `json:"Debug"`
-		BtminerFastBoot       string  `json:"Btminer Fast Boot"`
-	} `json:"SUMMARY"`
+	SUMMARY []SummaryInfo `json:"SUMMARY"`
+}
+
+// SummaryInfo is the single entry of a summary response.
+type SummaryInfo struct {
+	Elapsed               Float     `json:"Elapsed"`
+	MHSAv                 Float     `json:"MHS av"`
+	MHS5S                 Float     `json:"MHS 5s"`
+	MHS1M                 Float     `json:"MHS 1m"`
+	MHS5M                 Float     `json:"MHS 5m"`
+	MHS15M                Float     `json:"MHS 15m"`
+	HSRT                  Float     `json:"HS RT"`
+	Accepted              Float     `json:"Accepted"`
+	Rejected              Float     `json:"Rejected"`
+	TotalMH               Float     `json:"Total MH"`
+	Temperature           Float     `json:"Temperature"`
+	FreqAvg               Float     `json:"freq_avg"`
+	FanSpeedIn            Float     `json:"Fan Speed In"`
+	FanSpeedOut           Float     `json:"Fan Speed Out"`
+	Power                 Float     `json:"Power"`
+	PowerRate             Float     `json:"Power Rate"`
+	PoolRejected          Float     `json:"Pool Rejected%"`
+	PoolStale             Float     `json:"Pool Stale%"`
+	LastGetwork           Float     `json:"Last getwork"`
+	Uptime                Float     `json:"Uptime"`
+	SecurityMode          Float     `json:"Security Mode"`
+	HashStable            bool      `json:"Hash Stable"`
+	HashStableCostSeconds Float     `json:"Hash Stable Cost Seconds"`
+	HashDeviation         Float     `json:"Hash Deviation%"`
+	TargetFreq            Float     `json:"Target Freq"`
+	TargetMHS             Float     `json:"Target MHS"`
+	EnvTemp               Float     `json:"Env Temp"`
+	PowerMode             PowerMode `json:"Power Mode"`
+	FactoryGHS            Float     `json:"Factory GHS"`
+	PowerLimit            Float     `json:"Power Limit"`
+	ChipTempMin           Float     `json:"Chip Temp Min"`
+	ChipTempMax           Float     `json:"Chip Temp Max"`
+	ChipTempAvg           Float     `json:"Chip Temp Avg"`
+	Debug                 string    `json:"Debug"`
+	BtminerFastBoot       string    `json:"Btminer Fast Boot"`
 }
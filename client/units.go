@@ -0,0 +1,104 @@
+package client
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The unit types below wrap Float, so a value the miner did not report stays NaN through every
+// conversion and marshals as in Float.
+
+// Hashrate is a hash rate in TH/s.
+type Hashrate Float
+
+// HashrateFromMHS converts the MH/s the miner reports in "MHS av" and similar fields.
+func HashrateFromMHS(mhs Float) Hashrate { return Hashrate(mhs / 1e6) }
+
+// HashrateFromGHS converts the GH/s the miner reports in "Factory GHS" and "HS Factory".
+func HashrateFromGHS(ghs Float) Hashrate { return Hashrate(ghs / 1e3) }
+
+// TH returns h in TH/s.
+func (h Hashrate) TH() float64 { return float64(h) }
+
+// GH returns h in GH/s.
+func (h Hashrate) GH() float64 { return float64(h) * 1e3 }
+
+// MH returns h in MH/s.
+func (h Hashrate) MH() float64 { return float64(h) * 1e6 }
+
+func (h Hashrate) String() string { return formatUnit(Float(h), "TH/s") }
+
+func (h Hashrate) MarshalJSON() ([]byte, error) { return Float(h).MarshalJSON() }
+
+func (h *Hashrate) UnmarshalJSON(data []byte) error { return (*Float)(h).UnmarshalJSON(data) }
+
+// Watts is a power in W.
+type Watts Float
+
+// Kilowatts returns p in kW.
+func (p Watts) Kilowatts() float64 { return float64(p) / 1e3 }
+
+func (p Watts) String() string { return formatUnit(Float(p), "W") }
+
+func (p Watts) MarshalJSON() ([]byte, error) { return Float(p).MarshalJSON() }
+
+func (p *Watts) UnmarshalJSON(data []byte) error { return (*Float)(p).UnmarshalJSON(data) }
+
+// Efficiency is an energy efficiency in J/TH, which equals W per TH/s. Lower is better.
+type Efficiency Float
+
+// EfficiencyOf returns the efficiency of drawing p at hash rate h, or NaN if h is not positive.
+func EfficiencyOf(p Watts, h Hashrate) Efficiency {
+	if !(h > 0) {
+		return Efficiency(math.NaN())
+	}
+	return Efficiency(float64(p) / float64(h))
+}
+
+// JoulesPerGH returns e in J/GH.
+func (e Efficiency) JoulesPerGH() float64 { return float64(e) / 1e3 }
+
+func (e Efficiency) String() string { return formatUnit(Float(e), "J/TH") }
+
+func (e Efficiency) MarshalJSON() ([]byte, error) { return Float(e).MarshalJSON() }
+
+func (e *Efficiency) UnmarshalJSON(data []byte) error { return (*Float)(e).UnmarshalJSON(data) }
+
+// Celsius is a temperature in °C.
+type Celsius Float
+
+// Fahrenheit returns t in °F.
+func (t Celsius) Fahrenheit() float64 { return float64(t)*9/5 + 32 }
+
+func (t Celsius) String() string { return formatUnit(Float(t), "°C") }
+
+func (t Celsius) MarshalJSON() ([]byte, error) { return Float(t).MarshalJSON() }
+
+func (t *Celsius) UnmarshalJSON(data []byte) error { return (*Float)(t).UnmarshalJSON(data) }
+
+// RPM is a fan speed in revolutions per minute.
+type RPM Float
+
+func (r RPM) String() string { return formatUnit(Float(r), "RPM") }
+
+func (r RPM) MarshalJSON() ([]byte, error) { return Float(r).MarshalJSON() }
+
+func (r *RPM) UnmarshalJSON(data []byte) error { return (*Float)(r).UnmarshalJSON(data) }
+
+func formatUnit(f Float, unit string) string {
+	if !f.Valid() {
+		return f.String()
+	}
+	return strconv.FormatFloat(float64(f), 'f', 2, 64) + " " + unit
+}
+
+// parseFloat parses the numbers some responses send as strings, such as the get_psu values. An
+// empty or malformed string yields NaN.
+func parseFloat(s string) Float {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return Float(math.NaN())
+	}
+	return Float(v)
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUnitConversions(t *testing.T) {
+	nan := Float(math.NaN())
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"MH/s to TH/s", HashrateFromMHS(110_500_000).TH(), 110.5},
+		{"GH/s to TH/s", HashrateFromGHS(90_000).TH(), 90},
+		{"TH/s to GH/s", Hashrate(2.5).GH(), 2500},
+		{"TH/s to MH/s", Hashrate(2.5).MH(), 2_500_000},
+		{"W to kW", Watts(3350).Kilowatts(), 3.35},
+		{"efficiency", float64(EfficiencyOf(3300, 110)), 30},
+		{"J/TH to J/GH", Efficiency(30).JoulesPerGH(), 0.03},
+		{"°C to °F", Celsius(70).Fahrenheit(), 158},
+		{"°C to °F below zero", Celsius(-40).Fahrenheit(), -40},
+		{"NaN stays NaN", HashrateFromMHS(nan).TH(), math.NaN()},
+		{"efficiency at zero hash rate", float64(EfficiencyOf(3300, 0)), math.NaN()},
+		{"efficiency at unknown hash rate", float64(EfficiencyOf(3300, Hashrate(nan))), math.NaN()},
+		{"efficiency at unknown power", float64(EfficiencyOf(Watts(nan), 110)), math.NaN()},
+		{"string number", float64(parseFloat(" 230.5 ")), 230.5},
+		{"empty string", float64(parseFloat("")), math.NaN()},
+		{"malformed string", float64(parseFloat("12V")), math.NaN()},
+	}
+	for _, tt := range tests {
+		if math.IsNaN(tt.want) != math.IsNaN(tt.got) || !math.IsNaN(tt.want) && math.Abs(tt.got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestUnitStrings(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{Hashrate(110.5).String(), "110.50 TH/s"},
+		{Watts(3300).String(), "3300.00 W"},
+		{Efficiency(29.864).String(), "29.86 J/TH"},
+		{Celsius(70).String(), "70.00 °C"},
+		{RPM(4800).String(), "4800.00 RPM"},
+		{Watts(math.NaN()).String(), "NaN"},
+		{Efficiency(math.Inf(1)).String(), "Inf"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("String() = %q, want %q", tt.got, tt.want)
+		}
+	}
+}
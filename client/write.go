@@ -1,22 +1,30 @@
 package client
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
-		"github.com/GridlessCompute/wmapi/transport"
-)
-
-const (
-	LowPower    = "set_low_power"
-	NormalPower = "set_normal_power"
-	HighPower   = "set_high_power"
+	"github.com/GridlessCompute/wmapi/transport"
 )
 
 type WriteAPI struct {
 	API   *transport.WhatsminerAPI
 	Token *transport.WhatsminerAccessToken
+	// Protocol, if set, sends the commands instead of API, as for ReadAPI.
+	Protocol transport.Protocol
+	// Capabilities, if set, makes commands the firmware does not support fail with
+	// transport.ErrUnsupported without being sent.
+	Capabilities *Capabilities
+}
+
+// protocol returns what the commands are sent through.
+func (w *WriteAPI) protocol() transport.Protocol {
+	if w.Protocol != nil {
+		return w.Protocol
+	}
+	return w.API
 }
 
 type CustomLedSettings struct {
@@ -26,523 +34,334 @@ type CustomLedSettings struct {
 	Start    int    `json:"start"`
 }
 
-type CustomNetworkSettings struct {
-	Ip   string
-	Mask string
-	Gate string
-	Dns  string
-	Host string
+func (w *WriteAPI) Pools(pools ...Pool) (*CommandResponse, error) {
+	return w.PoolsContext(context.Background(), pools...)
 }
 
-func (w *WriteAPI) Pools(pools ...Pool) (*CommandResponse, error) {
+// PoolsContext is like Pools but binds the request to ctx.
+func (w *WriteAPI) PoolsContext(ctx context.Context, pools ...Pool) (*CommandResponse, error) {
 	if len(pools) == 0 || len(pools) > 3 {
-		return nil, fmt.Errorf("you must provide between 1 and 3 pools")
+		return nil, fmt.Errorf("%w: you must provide between 1 and 3 pools", ErrInvalidArgument)
 	}
 
 	params := make(map[string]any)
 	for i, p := range pools {
 		if p.URL == "" || p.Worker == "" {
-			return nil, fmt.Errorf("pool URL and worker cannot be empty for pool %d", i+1)
+			return nil, fmt.Errorf("%w: pool URL and worker cannot be empty for pool %d", ErrInvalidArgument, i+1)
 		}
 		params[fmt.Sprintf("pool%d", i+1)] = p.URL
-		params[fmt.Sprintf("user%d", i+1)] = p.Worker
+		params[fmt.Sprintf("worker%d", i+1)] = p.Worker
 		params[fmt.Sprintf("passwd%d", i+1)] = p.Password
 	}
 
-	result, err := w.API.ExecCommand(w.Token, "pools", params)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdUpdatePools, params)
 }
 
 // Reboot initiates a reboot of the miner
 func (w *WriteAPI) Restart() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "restart_btminer", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.RestartContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// RestartContext is like Restart but binds the request to ctx.
+func (w *WriteAPI) RestartContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdRestartBTMiner, nil)
 }
 
 func (w *WriteAPI) PowerOffHashboard() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "power_off", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.PowerOffHashboardContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// PowerOffHashboardContext is like PowerOffHashboard but binds the request to ctx.
+func (w *WriteAPI) PowerOffHashboardContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdPowerOff, nil)
 }
 
 func (w *WriteAPI) PowerOnHashboard() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "power_on", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.PowerOnHashboardContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// PowerOnHashboardContext is like PowerOnHashboard but binds the request to ctx.
+func (w *WriteAPI) PowerOnHashboardContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdPowerOn, nil)
 }
 
 func (w *WriteAPI) ManageLedRestore(mode string) (*CommandResponse, error) {
-	param := map[string]any{"param": mode}
-
-	result, err := w.API.ExecCommand(w.Token, "set_led", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
+	return w.ManageLedRestoreContext(context.Background(), mode)
+}
 
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+// ManageLedRestoreContext is like ManageLedRestore but binds the request to ctx.
+func (w *WriteAPI) ManageLedRestoreContext(ctx context.Context, mode string) (*CommandResponse, error) {
+	param := map[string]any{"param": mode}
 
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdSetLED, param)
 }
 
 func (w *WriteAPI) ManageLedCustom(settings CustomLedSettings) (*CommandResponse, error) {
+	return w.ManageLedCustomContext(context.Background(), settings)
+}
+
+// ManageLedCustomContext is like ManageLedCustom but binds the request to ctx.
+func (w *WriteAPI) ManageLedCustomContext(ctx context.Context, settings CustomLedSettings) (*CommandResponse, error) {
 	param := map[string]any{
 		"color":    settings.Color,
 		"period":   settings.Period,
 		"duration": settings.Duration,
 		"start":    settings.Start,
 	}
-	result, err := w.API.ExecCommand(w.Token, "set_led", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdSetLED, param)
 }
 
-func (w *WriteAPI) SwitchPowerMode(mode string) (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, mode, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
+// SwitchPowerMode switches the miner to LowPower, NormalPower or HighPower with set_low_power,
+// set_normal_power or set_high_power.
+func (w *WriteAPI) SwitchPowerMode(mode PowerMode) (*CommandResponse, error) {
+	return w.SwitchPowerModeContext(context.Background(), mode)
+}
 
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
+// SwitchPowerModeContext is like SwitchPowerMode but binds the request to ctx.
+func (w *WriteAPI) SwitchPowerModeContext(ctx context.Context, mode PowerMode) (*CommandResponse, error) {
+	cmd, ok := mode.command()
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid power mode %q", ErrInvalidArgument, string(mode))
 	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, cmd, nil)
 }
 
 func (w *WriteAPI) RebootSystem() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "reboot", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.RebootSystemContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// RebootSystemContext is like RebootSystem but binds the request to ctx.
+func (w *WriteAPI) RebootSystemContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdReboot, nil)
 }
 
 func (w *WriteAPI) RestoreFactorySettings() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "factory_reset", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.RestoreFactorySettingsContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// RestoreFactorySettingsContext is like RestoreFactorySettings but binds the request to ctx.
+func (w *WriteAPI) RestoreFactorySettingsContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdFactoryReset, nil)
 }
 
+// ModifyPassword changes the admin password with update_pwd. Once the miner has accepted it, the
+// token is re-derived from newPwd so the WriteAPI keeps working. The firmware accepts at most 8
+// letters, digits and underscores.
 func (w *WriteAPI) ModifyPassword(oldPwd, newPwd string) (*CommandResponse, error) {
+	return w.ModifyPasswordContext(context.Background(), oldPwd, newPwd)
+}
+
+// ModifyPasswordContext is like ModifyPassword but binds the request to ctx.
+func (w *WriteAPI) ModifyPasswordContext(ctx context.Context, oldPwd, newPwd string) (*CommandResponse, error) {
+	if !ValidPassword(newPwd) {
+		return nil, fmt.Errorf("%w: password must be 1 to 8 letters, digits or underscores", ErrInvalidArgument)
+	}
 	param := map[string]any{
 		"old": oldPwd,
 		"new": newPwd,
 	}
 
-	result, err := w.API.ExecCommand(w.Token, "factory_reset", param)
+	resp, err := Exec[CommandResponse](ctx, w, CmdUpdatePassword, param)
 	if err != nil {
 		return nil, err
 	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
+	if err := w.Token.UpdatePassword(ctx, newPwd); err != nil {
+		return resp, fmt.Errorf("password changed but the token could not be renewed: %w", err)
 	}
+	return resp, nil
+}
 
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+// PasswordChars are the characters the firmware accepts in an admin password.
+const PasswordChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_"
 
-	return &cmdResponse, nil
-}
+// MaxPasswordLen is the length limit the firmware puts on an admin password.
+const MaxPasswordLen = 8
 
-func (w *WriteAPI) NetworkSetDHCP() (*CommandResponse, error) {
-	param := map[string]any{"param": "dhcp"}
-	result, err := w.API.ExecCommand(w.Token, "factory_reset", param)
-	if err != nil {
-		return nil, err
+// ValidPassword reports whether the firmware accepts pwd as admin password: 1 to MaxPasswordLen
+// of PasswordChars.
+func ValidPassword(pwd string) bool {
+	if pwd == "" || len(pwd) > MaxPasswordLen {
+		return false
 	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
+	for _, c := range pwd {
+		if !strings.ContainsRune(PasswordChars, c) {
+			return false
+		}
 	}
+	return true
+}
 
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+// NetworkSetDHCP switches the miner to DHCP. Its new address is only known to the DHCP server.
+func (w *WriteAPI) NetworkSetDHCP() (*CommandResponse, error) {
+	return w.NetworkSetDHCPContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// NetworkSetDHCPContext is like NetworkSetDHCP but binds the request to ctx.
+func (w *WriteAPI) NetworkSetDHCPContext(ctx context.Context) (*CommandResponse, error) {
+	param := map[string]any{"param": "dhcp"}
+	return Exec[CommandResponse](ctx, w, CmdNetConfig, param)
 }
 
+// NetworkSetCustom switches the miner to the static configuration conf. conf is validated and,
+// before anything is changed, checked against the miner's current get_miner_info: it fails with
+// ErrAddressInUse if another miner already answers at the new address. Use NetworkSetCustomVerify
+// to also confirm the miner is reachable afterwards.
 func (w *WriteAPI) NetworkSetCustom(conf CustomNetworkSettings) (*CommandResponse, error) {
-	param := map[string]any{
-		"ip":   conf.Ip,
-		"mask": conf.Mask,
-		"gate": conf.Gate,
-		"dns":  conf.Dns,
-		"host": conf.Host,
-	}
+	return w.NetworkSetCustomContext(context.Background(), conf)
+}
 
-	result, err := w.API.ExecCommand(w.Token, "factory_reset", param)
-	if err != nil {
+// NetworkSetCustomContext is like NetworkSetCustom but binds the request to ctx.
+func (w *WriteAPI) NetworkSetCustomContext(ctx context.Context, conf CustomNetworkSettings) (*CommandResponse, error) {
+	if _, err := w.preflightNetwork(ctx, conf); err != nil {
 		return nil, err
 	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdNetConfig, conf.params())
 }
 
+// TargetFreq sets the target frequency with set_target_freq as a percentage offset from the
+// nominal frequency. tgt is clamped to [-100, 100]. Use TargetFreqVerify to wait until the miner
+// reports the new target.
 func (w *WriteAPI) TargetFreq(tgt int) (*CommandResponse, error) {
-	tgt = min(tgt, 100)
-	tgt = max(tgt, -100)
-	param := map[string]any{"percent": tgt}
-	result, err := w.API.ExecCommand(w.Token, "factory_reset", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
+	return w.TargetFreqContext(context.Background(), tgt)
+}
 
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+// TargetFreqContext is like TargetFreq but binds the request to ctx.
+func (w *WriteAPI) TargetFreqContext(ctx context.Context, tgt int) (*CommandResponse, error) {
+	param := map[string]any{"percent": clampPercent(tgt)}
+	return Exec[CommandResponse](ctx, w, CmdSetTargetFreq, param)
+}
 
-	return &cmdResponse, nil
+func clampPercent(tgt int) int {
+	return max(min(tgt, 100), -100)
 }
 
 func (w *WriteAPI) EnableFastboot() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "enable_btminer_fast_boot", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.EnableFastbootContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// EnableFastbootContext is like EnableFastboot but binds the request to ctx.
+func (w *WriteAPI) EnableFastbootContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdEnableFastBoot, nil)
 }
 
 func (w *WriteAPI) Disablefastboot() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "disable_btminer_fast_boot", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.DisablefastbootContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// DisablefastbootContext is like Disablefastboot but binds the request to ctx.
+func (w *WriteAPI) DisablefastbootContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdDisableFastBoot, nil)
 }
 
 func (w *WriteAPI) EnableWebPools() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "enable_web_pools", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.EnableWebPoolsContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// EnableWebPoolsContext is like EnableWebPools but binds the request to ctx.
+func (w *WriteAPI) EnableWebPoolsContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdEnableWebPools, nil)
 }
 
 func (w *WriteAPI) DisableWebPools() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "disable_web_pools", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.DisableWebPoolsContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// DisableWebPoolsContext is like DisableWebPools but binds the request to ctx.
+func (w *WriteAPI) DisableWebPoolsContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdDisableWebPools, nil)
 }
 
 func (w *WriteAPI) ChangeHostName(name string) (*CommandResponse, error) {
-	param := map[string]any{"hostname": name}
-	result, err := w.API.ExecCommand(w.Token, "set_hostname", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.ChangeHostNameContext(context.Background(), name)
+}
 
-	return &cmdResponse, nil
+// ChangeHostNameContext is like ChangeHostName but binds the request to ctx.
+func (w *WriteAPI) ChangeHostNameContext(ctx context.Context, name string) (*CommandResponse, error) {
+	param := map[string]any{"hostname": name}
+	return Exec[CommandResponse](ctx, w, CmdSetHostname, param)
 }
 
 func (w *WriteAPI) PowerPercent(pct int) (*CommandResponse, error) {
+	return w.PowerPercentContext(context.Background(), pct)
+}
+
+// PowerPercentContext is like PowerPercent but binds the request to ctx.
+func (w *WriteAPI) PowerPercentContext(ctx context.Context, pct int) (*CommandResponse, error) {
 	pct = min(pct, 100)
 	pct = max(pct, 0)
 
 	pctStr := strconv.Itoa(pct)
 
 	param := map[string]any{"percent": pctStr}
-	result, err := w.API.ExecCommand(w.Token, "set_power_pct", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdSetPowerPercent, param)
 }
 
 func (w *WriteAPI) PowerPercentV2(pct int) (*CommandResponse, error) {
+	return w.PowerPercentV2Context(context.Background(), pct)
+}
+
+// PowerPercentV2Context is like PowerPercentV2 but binds the request to ctx.
+func (w *WriteAPI) PowerPercentV2Context(ctx context.Context, pct int) (*CommandResponse, error) {
 	pct = min(pct, 100)
 	pct = max(pct, 0)
 
 	pctStr := strconv.Itoa(pct)
 
 	param := map[string]any{"percent": pctStr}
-	result, err := w.API.ExecCommand(w.Token, "set_power_pct_v2", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdSetPowerPercentV2, param)
 }
 
 func (w *WriteAPI) TempOffset(offset int) (*CommandResponse, error) {
+	return w.TempOffsetContext(context.Background(), offset)
+}
+
+// TempOffsetContext is like TempOffset but binds the request to ctx.
+func (w *WriteAPI) TempOffsetContext(ctx context.Context, offset int) (*CommandResponse, error) {
 	offset = min(offset, 0)
 	offset = max(offset, -30)
 
 	pctStr := strconv.Itoa(offset)
 
 	param := map[string]any{"temp_offset": pctStr}
-	result, err := w.API.ExecCommand(w.Token, "set_temp_offset", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdSetTempOffset, param)
 }
 
 func (w *WriteAPI) AdjPowerLimit(limit int) (*CommandResponse, error) {
+	return w.AdjPowerLimitContext(context.Background(), limit)
+}
+
+// AdjPowerLimitContext is like AdjPowerLimit but binds the request to ctx.
+func (w *WriteAPI) AdjPowerLimitContext(ctx context.Context, limit int) (*CommandResponse, error) {
 	limit = min(limit, 99999)
 	limit = max(limit, 0)
 
 	pctStr := strconv.Itoa(limit)
 
 	param := map[string]any{"power_limit": pctStr}
-	result, err := w.API.ExecCommand(w.Token, "adjust_power_limit", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdAdjustPowerLimit, param)
 }
 
 func (w *WriteAPI) AdjUpfreqSpeed(speed int) (*CommandResponse, error) {
+	return w.AdjUpfreqSpeedContext(context.Background(), speed)
+}
+
+// AdjUpfreqSpeedContext is like AdjUpfreqSpeed but binds the request to ctx.
+func (w *WriteAPI) AdjUpfreqSpeedContext(ctx context.Context, speed int) (*CommandResponse, error) {
 	speed = min(speed, 9)
 	speed = max(speed, 0)
 
 	pctStr := strconv.Itoa(speed)
 
 	param := map[string]any{"upfreq_speed": pctStr}
-	result, err := w.API.ExecCommand(w.Token, "adjust_upfreq_speed", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdAdjustUpfreqSpeed, param)
 }
 
 func (w *WriteAPI) PowerOffCool(cool bool) (*CommandResponse, error) {
+	return w.PowerOffCoolContext(context.Background(), cool)
+}
+
+// PowerOffCoolContext is like PowerOffCool but binds the request to ctx.
+func (w *WriteAPI) PowerOffCoolContext(ctx context.Context, cool bool) (*CommandResponse, error) {
 	c := "0"
 
 	if cool {
@@ -550,25 +369,15 @@ func (w *WriteAPI) PowerOffCool(cool bool) (*CommandResponse, error) {
 	}
 
 	param := map[string]any{"poweroff_cool": c}
-	result, err := w.API.ExecCommand(w.Token, "set_poweroff_cool", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdSetPowerOffCool, param)
 }
 
 func (w *WriteAPI) FanZeroSpeed(zero bool) (*CommandResponse, error) {
+	return w.FanZeroSpeedContext(context.Background(), zero)
+}
+
+// FanZeroSpeedContext is like FanZeroSpeed but binds the request to ctx.
+func (w *WriteAPI) FanZeroSpeedContext(ctx context.Context, zero bool) (*CommandResponse, error) {
 	z := "0"
 
 	if zero {
@@ -576,59 +385,23 @@ func (w *WriteAPI) FanZeroSpeed(zero bool) (*CommandResponse, error) {
 	}
 
 	param := map[string]any{"fan_zero_speed": z}
-	result, err := w.API.ExecCommand(w.Token, "set_fan_zero_speed", param)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return Exec[CommandResponse](ctx, w, CmdSetFanZeroSpeed, param)
 }
 
 func (w *WriteAPI) DisableBTMinerInit() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "disbale_btminer_init", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
+	return w.DisableBTMinerInitContext(context.Background())
+}
 
-	return &cmdResponse, nil
+// DisableBTMinerInitContext is like DisableBTMinerInit but binds the request to ctx.
+func (w *WriteAPI) DisableBTMinerInitContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdDisableBTMinerInit, nil)
 }
 
 func (w *WriteAPI) EnableBTMinerInit() (*CommandResponse, error) {
-	result, err := w.API.ExecCommand(w.Token, "enable_btminer_init", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result to json: %w", err)
-	}
-
-	var cmdResponse CommandResponse
-	if err := json.Unmarshal(jsonBytes, &cmdResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json to CommandResponse: %w", err)
-	}
-
-	return &cmdResponse, nil
+	return w.EnableBTMinerInitContext(context.Background())
 }
 
+// EnableBTMinerInitContext is like EnableBTMinerInit but binds the request to ctx.
+func (w *WriteAPI) EnableBTMinerInitContext(ctx context.Context) (*CommandResponse, error) {
+	return Exec[CommandResponse](ctx, w, CmdEnableBTMinerInit, nil)
+}
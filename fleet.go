@@ -0,0 +1,295 @@
+package wmapi
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+)
+
+// Selector chooses the miners of a Fleet an operation runs on. A nil Selector selects all.
+type Selector func(id string, m *WhatsminerMiddleware) bool
+
+// SelectIDs selects the miners with the given IDs.
+func SelectIDs(ids ...string) Selector {
+	return func(id string, _ *WhatsminerMiddleware) bool { return slices.Contains(ids, id) }
+}
+
+// FleetError aggregates the failures of a Fleet operation by miner ID. It unwraps to the failures,
+// so errors.Is and errors.As see each of them.
+type FleetError struct {
+	// Total is the number of miners the operation ran on.
+	Total int
+	Errs  map[string]error
+}
+
+func (e *FleetError) Error() string {
+	ids := slices.Sorted(maps.Keys(e.Errs))
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d miners failed", len(ids), e.Total)
+	for _, id := range ids {
+		fmt.Fprintf(&b, "\n%s: %v", id, e.Errs[id])
+	}
+	return b.String()
+}
+
+func (e *FleetError) Unwrap() []error {
+	return slices.Collect(maps.Values(e.Errs))
+}
+
+// FleetMember describes a miner for Fleet.Connect.
+type FleetMember struct {
+	ID       string
+	Address  string
+	Port     int // zero means 4028
+	Password string
+}
+
+// Fleet holds the middlewares of many miners by ID and runs operations across them with bounded
+// concurrency. The zero value is an empty fleet ready to use. A Fleet is safe for concurrent use.
+type Fleet struct {
+	// Concurrency bounds how many miners an operation works on at once; zero means 64. Each miner
+	// is further bounded by the connection limit of its transport.
+	Concurrency int
+	// Timeout bounds the operation on each miner; zero means only the context passed in bounds it.
+	Timeout time.Duration
+
+	mu     sync.RWMutex
+	miners map[string]*fleetMiner
+}
+
+// fleetMiner is a middleware held by a Fleet together with the operations using it.
+type fleetMiner struct {
+	m *WhatsminerMiddleware
+	// inUse counts the Do and Collect calls that selected the miner. It is only incremented while
+	// the miner is in the fleet, under the fleet's lock.
+	inUse sync.WaitGroup
+}
+
+// close closes the middleware once the operations using it have finished. It does not wait for
+// them itself, so an operation may remove the miner it works on.
+func (fm *fleetMiner) close() {
+	go func() {
+		fm.inUse.Wait()
+		fm.m.Close()
+	}()
+}
+
+// Add puts m in the fleet under id, replacing any middleware already held under it. The replaced
+// middleware is closed once the operations using it have finished.
+func (f *Fleet) Add(id string, m *WhatsminerMiddleware) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.miners == nil {
+		f.miners = make(map[string]*fleetMiner)
+	}
+	if prev, ok := f.miners[id]; ok {
+		if prev.m == m {
+			return
+		}
+		prev.close()
+	}
+	f.miners[id] = &fleetMiner{m: m}
+}
+
+// Remove takes the middleware under id out of the fleet. It is closed once the operations using it
+// have finished.
+func (f *Fleet) Remove(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if fm, ok := f.miners[id]; ok {
+		fm.close()
+		delete(f.miners, id)
+	}
+}
+
+// Get returns the middleware under id. Unlike Do, using it does not keep Remove from closing it.
+func (f *Fleet) Get(id string) (*WhatsminerMiddleware, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	fm, ok := f.miners[id]
+	if !ok {
+		return nil, false
+	}
+	return fm.m, true
+}
+
+// IDs returns the IDs of the miners in the fleet, sorted.
+func (f *Fleet) IDs() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return slices.Sorted(maps.Keys(f.miners))
+}
+
+// Len returns the number of miners in the fleet.
+func (f *Fleet) Len() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.miners)
+}
+
+// Close removes every miner in the fleet. Each is closed once the operations using it have
+// finished.
+func (f *Fleet) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, fm := range f.miners {
+		fm.close()
+	}
+	f.miners = nil
+}
+
+// Connect connects to members concurrently, as Do runs operations, and adds each miner that
+// connected. opts configure every connection.
+//
+// It returns the outcome of every member by ID, nil for those that connected, and a *FleetError
+// if any failed.
+func (f *Fleet) Connect(ctx context.Context, members []FleetMember, opts ...transport.Option) (map[string]error, error) {
+	byID := make(map[string]FleetMember, len(members))
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		if _, ok := byID[member.ID]; !ok {
+			ids = append(ids, member.ID)
+		}
+		byID[member.ID] = member
+	}
+
+	return f.run(ctx, ids, func(ctx context.Context, id string) error {
+		member := byID[id]
+		port := member.Port
+		if port == 0 {
+			port = 4028
+		}
+		m, err := NewWhatsminerAPIContext(ctx, member.Address, port, member.Password, opts...)
+		if err != nil {
+			return err
+		}
+		f.Add(id, m)
+		return nil
+	})
+}
+
+// Do runs op on every miner sel selects. At most Concurrency miners are worked on at once, each
+// with a context bounded by Timeout; op must honour it. Miners not yet started when ctx ends fail
+// with ctx's error. A miner removed or replaced meanwhile stays open until Do returns.
+//
+// It returns the outcome of every selected miner by ID, nil for those op succeeded on, and a
+// *FleetError if any failed.
+func (f *Fleet) Do(ctx context.Context, sel Selector, op func(ctx context.Context, m *WhatsminerMiddleware) error) (map[string]error, error) {
+	selected := f.acquire(sel)
+	defer release(selected)
+	return f.run(ctx, slices.Sorted(maps.Keys(selected)), func(ctx context.Context, id string) error {
+		return op(ctx, selected[id].m)
+	})
+}
+
+// Collect is like Fleet.Do for operations that return a value. The map holds the values of the
+// miners op succeeded on; the failures are in the *FleetError.
+func Collect[T any](ctx context.Context, f *Fleet, sel Selector, op func(ctx context.Context, m *WhatsminerMiddleware) (T, error)) (map[string]T, error) {
+	var mu sync.Mutex
+	values := make(map[string]T)
+	selected := f.acquire(sel)
+	defer release(selected)
+	_, err := f.run(ctx, slices.Sorted(maps.Keys(selected)), func(ctx context.Context, id string) error {
+		v, err := op(ctx, selected[id].m)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		values[id] = v
+		return nil
+	})
+	return values, err
+}
+
+// acquire returns the miners sel selects by ID and marks them in use until they are released.
+func (f *Fleet) acquire(sel Selector) map[string]*fleetMiner {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	selected := make(map[string]*fleetMiner)
+	for id, fm := range f.miners {
+		if sel == nil || sel(id, fm.m) {
+			fm.inUse.Add(1)
+			selected[id] = fm
+		}
+	}
+	return selected
+}
+
+// release ends the use of miners returned by acquire.
+func release(miners map[string]*fleetMiner) {
+	for _, fm := range miners {
+		fm.inUse.Done()
+	}
+}
+
+// run calls fn for every ID on a pool of Concurrency workers.
+func (f *Fleet) run(ctx context.Context, ids []string, fn func(ctx context.Context, id string) error) (map[string]error, error) {
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = 64
+	}
+
+	var mu sync.Mutex
+	results := make(map[string]error, len(ids))
+	record := func(id string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[id] = err
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(ids)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				record(id, f.runOne(ctx, id, fn))
+			}
+		}()
+	}
+
+	for i, id := range ids {
+		select {
+		case jobs <- id:
+			continue
+		case <-ctx.Done():
+		}
+		for _, id := range ids[i:] {
+			record(id, ctx.Err())
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	errs := make(map[string]error)
+	for id, err := range results {
+		if err != nil {
+			errs[id] = err
+		}
+	}
+	if len(errs) > 0 {
+		return results, &FleetError{Total: len(ids), Errs: errs}
+	}
+	return results, nil
+}
+
+func (f *Fleet) runOne(ctx context.Context, id string, fn func(ctx context.Context, id string) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+	return fn(ctx, id)
+}
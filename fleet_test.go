@@ -0,0 +1,230 @@
+package wmapi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi"
+	"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// TestFleetRemoveDuringDo removes miners while Do works on them and checks that the operations
+// already running are unaffected.
+func TestFleetRemoveDuringDo(t *testing.T) {
+	var members []wmapi.FleetMember
+	for _, id := range []string{"a", "b", "c"} {
+		sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+		if err := sim.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer sim.Close()
+		members = append(members, wmapi.FleetMember{ID: id, Address: sim.Host(), Port: sim.Port(), Password: sim.Password()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var fleet wmapi.Fleet
+	defer fleet.Close()
+	if _, err := fleet.Connect(ctx, members); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	_, err := fleet.Do(ctx, nil, func(ctx context.Context, m *wmapi.WhatsminerMiddleware) error {
+		for _, id := range fleet.IDs() {
+			fleet.Remove(id)
+		}
+		_, err := m.Write.EnableFastbootContext(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if n := fleet.Len(); n != 0 {
+		t.Errorf("Len = %d after removing every miner, want 0", n)
+	}
+}
+
+// startFleet connects a fleet to n simulated miners with the IDs m0, m1, ...
+func startFleet(t *testing.T, fleet *wmapi.Fleet, n int) []string {
+	t.Helper()
+	var members []wmapi.FleetMember
+	var ids []string
+	for i := range n {
+		sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+		if err := sim.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sim.Close() })
+		id := fmt.Sprintf("m%d", i)
+		ids = append(ids, id)
+		members = append(members, wmapi.FleetMember{ID: id, Address: sim.Host(), Port: sim.Port(), Password: sim.Password()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := fleet.Connect(ctx, members); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(fleet.Close)
+	return ids
+}
+
+func TestFleetConcurrency(t *testing.T) {
+	fleet := &wmapi.Fleet{Concurrency: 2}
+	startFleet(t, fleet, 6)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var active, peak atomic.Int32
+	_, err := fleet.Do(ctx, nil, func(ctx context.Context, m *wmapi.WhatsminerMiddleware) error {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if p := peak.Load(); p != 2 {
+		t.Errorf("at most %d miners were worked on at once, want 2", p)
+	}
+}
+
+func TestFleetTimeout(t *testing.T) {
+	fleet := &wmapi.Fleet{Timeout: 50 * time.Millisecond}
+	ids := startFleet(t, fleet, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	results, err := fleet.Do(ctx, nil, func(ctx context.Context, m *wmapi.WhatsminerMiddleware) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Do took %v, want each miner to time out after 50ms", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+		t.Errorf("Do = %v, want the per-miner deadline while ctx is still live", err)
+	}
+	for _, id := range ids {
+		if !errors.Is(results[id], context.DeadlineExceeded) {
+			t.Errorf("%s: %v, want context.DeadlineExceeded", id, results[id])
+		}
+	}
+}
+
+func TestFleetError(t *testing.T) {
+	var fleet wmapi.Fleet
+	startFleet(t, &fleet, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	errBoom := errors.New("boom")
+	results, err := fleet.Do(ctx, nil, func(ctx context.Context, m *wmapi.WhatsminerMiddleware) error {
+		if m == mustGet(t, &fleet, "m1") {
+			return fmt.Errorf("m1 failed: %w", errBoom)
+		}
+		if m == mustGet(t, &fleet, "m2") {
+			// The miner refuses an unknown command with a *transport.MinerError.
+			_, err := client.Read[client.CommandResponse](ctx, m.Read, "no_such_command", nil)
+			return err
+		}
+		return nil
+	})
+
+	var fleetErr *wmapi.FleetError
+	if !errors.As(err, &fleetErr) {
+		t.Fatalf("Do = %v, want a *FleetError", err)
+	}
+	if fleetErr.Total != 3 || len(fleetErr.Errs) != 2 || results["m0"] != nil {
+		t.Errorf("FleetError = %+v, results = %v, want m1 and m2 of 3 failed", fleetErr, results)
+	}
+	if !errors.Is(err, errBoom) {
+		t.Errorf("errors.Is(%v, errBoom) = false", err)
+	}
+	var minerErr *transport.MinerError
+	if !errors.As(err, &minerErr) {
+		t.Errorf("errors.As(%v, *transport.MinerError) = false", err)
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "2 of 3 miners failed\nm1: ") || !strings.Contains(msg, "\nm2: ") {
+		t.Errorf("Error() = %q", msg)
+	}
+}
+
+func mustGet(t *testing.T, fleet *wmapi.Fleet, id string) *wmapi.WhatsminerMiddleware {
+	m, ok := fleet.Get(id)
+	if !ok {
+		t.Fatalf("%s is not in the fleet", id)
+	}
+	return m
+}
+
+// TestFleetCancel cancels ctx during the first operation and checks that no further miner is
+// started.
+func TestFleetCancel(t *testing.T) {
+	fleet := &wmapi.Fleet{Concurrency: 1}
+	ids := startFleet(t, fleet, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	results, err := fleet.Do(ctx, nil, func(ctx context.Context, m *wmapi.WhatsminerMiddleware) error {
+		calls.Add(1)
+		cancel()
+		return nil
+	})
+	if n := calls.Load(); n != 1 {
+		t.Errorf("op ran on %d miners, want 1", n)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Do = %v, want context.Canceled", err)
+	}
+	if results[ids[0]] != nil {
+		t.Errorf("%s: %v, want the operation that ran to succeed", ids[0], results[ids[0]])
+	}
+	for _, id := range ids[1:] {
+		if !errors.Is(results[id], context.Canceled) {
+			t.Errorf("%s: %v, want context.Canceled", id, results[id])
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	var fleet wmapi.Fleet
+	startFleet(t, &fleet, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	values, err := wmapi.Collect(ctx, &fleet, wmapi.SelectIDs("m0", "m2"), func(ctx context.Context, m *wmapi.WhatsminerMiddleware) (string, error) {
+		if m == mustGet(t, &fleet, "m2") {
+			return "", errors.New("unreachable")
+		}
+		version, err := m.Read.VersionContext(ctx)
+		if err != nil {
+			return "", err
+		}
+		return version.Msg.APIVer, nil
+	})
+
+	var fleetErr *wmapi.FleetError
+	if !errors.As(err, &fleetErr) || fleetErr.Total != 2 || fleetErr.Errs["m2"] == nil {
+		t.Errorf("Collect = %v, want a *FleetError for m2 of 2 miners", err)
+	}
+	if len(values) != 1 || values["m0"] == "" {
+		t.Errorf("values = %v, want the api_ver of m0 only", values)
+	}
+}
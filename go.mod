@@ -5,4 +5,8 @@ go 1.25.0
 require (
 	github.com/GehirnInc/crypt v0.0.0-20230320061759-8cc1b52080c5
 	github.com/andreburgaud/crypt2go v1.8.0
+	golang.org/x/crypto v0.50.0
+	golang.org/x/net v0.52.0
 )
+
+require golang.org/x/sys v0.43.0 // indirect
@@ -0,0 +1,229 @@
+package wmapi
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"sync"
+
+	"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/transport"
+)
+
+// ErrPasswordUnverified is reported when a miner accepted a new password but could not be
+// authenticated with it afterwards.
+var ErrPasswordUnverified = errors.New("new password could not be verified")
+
+// CredentialStore holds the admin password of each miner by address. Implementations must be safe
+// for concurrent use.
+type CredentialStore interface {
+	Password(ctx context.Context, address string) (string, error)
+	SetPassword(ctx context.Context, address, password string) error
+}
+
+// MemoryCredentialStore is a CredentialStore kept in memory.
+type MemoryCredentialStore struct {
+	mu        sync.Mutex
+	passwords map[string]string
+}
+
+// NewMemoryCredentialStore returns a store holding a copy of passwords.
+func NewMemoryCredentialStore(passwords map[string]string) *MemoryCredentialStore {
+	s := &MemoryCredentialStore{passwords: make(map[string]string, len(passwords))}
+	maps.Copy(s.passwords, passwords)
+	return s
+}
+
+func (s *MemoryCredentialStore) Password(ctx context.Context, address string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pwd, ok := s.passwords[address]
+	if !ok {
+		return "", fmt.Errorf("no password stored for %s", address)
+	}
+	return pwd, nil
+}
+
+func (s *MemoryCredentialStore) SetPassword(ctx context.Context, address, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.passwords[address] = password
+	return nil
+}
+
+// GeneratePassword returns a random password of the maximum length the firmware accepts, drawn
+// uniformly from client.PasswordChars.
+func GeneratePassword() (string, error) {
+	const n = len(client.PasswordChars)
+	// Bytes at or above the largest multiple of n would favour the first characters.
+	const limit = 256 - 256%n
+
+	pwd := make([]byte, 0, client.MaxPasswordLen)
+	buf := make([]byte, client.MaxPasswordLen)
+	for len(pwd) < client.MaxPasswordLen {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(pwd) < client.MaxPasswordLen {
+				pwd = append(pwd, client.PasswordChars[int(b)%n])
+			}
+		}
+	}
+	return string(pwd), nil
+}
+
+// PasswordRotation changes the admin password of many miners.
+type PasswordRotation struct {
+	// Store supplies the current passwords and receives the new ones.
+	Store CredentialStore
+	// Port is the API port of every miner; zero means 4028.
+	Port int
+	// NewPassword returns the password to set on a miner. Nil means GeneratePassword.
+	NewPassword func(address string) (string, error)
+	// Concurrency bounds how many miners are changed at once; zero means 8.
+	Concurrency int
+	// Options configure the connections to the miners.
+	Options []transport.Option
+}
+
+// RotationResult is the outcome of a rotation for one miner.
+type RotationResult struct {
+	Address string
+	// Changed is set once the miner acknowledged the new password, Verified once a new session
+	// authenticated with it and Stored once the store holds it.
+	Changed  bool
+	Verified bool
+	Stored   bool
+	// Password is the new password. It is set whenever the miner may have accepted it, so it is
+	// not lost if verifying or storing it fails. Callers must persist it themselves when Stored
+	// is not set, or the miner may be left with a password nobody knows.
+	Password string
+	Err      error
+}
+
+// Run rotates the passwords of addresses and returns one result per address, in the same order.
+// A new password is written to the store once the miner acknowledged it, even if authenticating a
+// fresh session with it fails afterwards; such results are Stored but carry ErrPasswordUnverified.
+// If the change itself failed in a way that leaves open whether the miner applied it, the password
+// is only stored once it has been verified.
+func (r *PasswordRotation) Run(ctx context.Context, addresses ...string) []RotationResult {
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	results := make([]RotationResult, len(addresses))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, addr := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+				results[i] = r.rotate(ctx, addr)
+			case <-ctx.Done():
+				results[i] = RotationResult{Address: addr, Err: ctx.Err()}
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// rotate changes, verifies and stores the password of a single miner.
+func (r *PasswordRotation) rotate(ctx context.Context, addr string) RotationResult {
+	res := RotationResult{Address: addr}
+	port := r.Port
+	if port == 0 {
+		port = 4028
+	}
+
+	oldPwd, err := r.Store.Password(ctx, addr)
+	if err != nil {
+		res.Err = fmt.Errorf("failed to look up current password: %w", err)
+		return res
+	}
+	newPwd, err := r.newPassword(addr)
+	if err != nil {
+		res.Err = fmt.Errorf("failed to generate new password: %w", err)
+		return res
+	}
+
+	mw, err := NewWhatsminerAPIContext(ctx, addr, port, oldPwd, r.Options...)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer mw.AccessToken.Close()
+
+	resp, err := mw.Write.ModifyPasswordContext(ctx, oldPwd, newPwd)
+	res.Changed = resp != nil
+	if err != nil {
+		res.Err = fmt.Errorf("failed to change password: %w", err)
+		if !res.Changed && (transport.NotExecuted(err) || errors.Is(err, client.ErrInvalidArgument)) {
+			// The change was never sent or was refused; the miner still has the old password.
+			return res
+		}
+		// The connection may have failed after the miner applied the change; only verifying
+		// tells.
+	}
+	res.Password = newPwd
+
+	if err := r.verify(ctx, addr, port, newPwd); err != nil {
+		if !res.Changed {
+			return res
+		}
+		// The miner acknowledged the password, so it is stored even though it could not be
+		// verified; keeping the old one would lock the miner out.
+		res.Err = fmt.Errorf("%w: %w", ErrPasswordUnverified, err)
+		if err := r.Store.SetPassword(ctx, addr, newPwd); err != nil {
+			res.Err = fmt.Errorf("%w; storing it failed: %w", res.Err, err)
+			return res
+		}
+		res.Stored = true
+		return res
+	}
+	res.Changed, res.Verified, res.Err = true, true, nil
+
+	if err := r.Store.SetPassword(ctx, addr, newPwd); err != nil {
+		res.Err = fmt.Errorf("miner uses the new password but storing it failed: %w", err)
+		return res
+	}
+	res.Stored = true
+	return res
+}
+
+// verify authenticates a fresh session with pwd and re-applies the target frequency offset the
+// miner already runs at. set_target_freq is a documented signed write on API 2.x and 3.x, so it
+// only succeeds if the miner's password is pwd, and setting the current offset changes nothing.
+func (r *PasswordRotation) verify(ctx context.Context, addr string, port int, pwd string) error {
+	mw, err := NewWhatsminerAPIContext(ctx, addr, port, pwd, r.Options...)
+	if err != nil {
+		return err
+	}
+	defer mw.AccessToken.Close()
+
+	status, err := mw.Read.StatusContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the current target frequency: %w", err)
+	}
+	pct, err := strconv.Atoi(status.Msg.HashPercent)
+	if err != nil {
+		return fmt.Errorf("%w: status reported hash_percent %q", transport.ErrInvalidResponse, status.Msg.HashPercent)
+	}
+	_, err = mw.Write.TargetFreqContext(ctx, pct-100)
+	return err
+}
+
+func (r *PasswordRotation) newPassword(addr string) (string, error) {
+	if r.NewPassword == nil {
+		return GeneratePassword()
+	}
+	return r.NewPassword(addr)
+}
@@ -0,0 +1,123 @@
+package wmapi_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi"
+	"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// TestPasswordRotation rotates the password of a simulated miner and checks that it is changed
+// with a single update_pwd and verified by re-applying the current target frequency.
+func TestPasswordRotation(t *testing.T) {
+	for _, apiVersion := range []string{"2.0.5", "3.0.1"} {
+		t.Run("API "+apiVersion, func(t *testing.T) {
+			state := wmapisim.DefaultState()
+			state.APIVersion = apiVersion
+			state.TargetFreqPercent = -5
+			sim := wmapisim.NewServer("admin", state)
+			if err := sim.Start(); err != nil {
+				t.Fatal(err)
+			}
+			if err := sim.StartV3(); err != nil {
+				t.Fatal(err)
+			}
+			defer sim.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			store := wmapi.NewMemoryCredentialStore(map[string]string{sim.Host(): sim.Password()})
+			opts := []transport.Option{transport.WithV3Port(sim.V3Port())}
+			rotation := &wmapi.PasswordRotation{Store: store, Port: sim.Port(), Options: opts}
+
+			res := rotation.Run(ctx, sim.Host())[0]
+			if res.Err != nil || !res.Changed || !res.Verified || !res.Stored {
+				t.Fatalf("result = %+v, want changed, verified and stored", res)
+			}
+			if !client.ValidPassword(res.Password) || sim.Password() != res.Password {
+				t.Errorf("new password %q, simulator has %q", res.Password, sim.Password())
+			}
+			if stored, _ := store.Password(ctx, sim.Host()); stored != res.Password {
+				t.Errorf("stored password %q, want %q", stored, res.Password)
+			}
+			if got := sim.State().TargetFreqPercent; got != -5 {
+				t.Errorf("target frequency offset = %d after verifying, want -5", got)
+			}
+
+			mw, err := wmapi.NewWhatsminerAPIContext(ctx, sim.Host(), sim.Port(), "admin", opts...)
+			if err != nil {
+				t.Fatalf("NewWhatsminerAPIContext: %v", err)
+			}
+			defer mw.Close()
+			if _, err := mw.Write.TargetFreqContext(ctx, -5); err == nil {
+				t.Error("signed write with the old password succeeded")
+			}
+		})
+	}
+}
+
+// TestPasswordRotationFailures checks what is reported and stored when the change is refused and
+// when the miner acknowledges it but the new password cannot be verified.
+func TestPasswordRotationFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		fault  wmapisim.Fault
+		stored bool
+	}{
+		{"change refused", wmapisim.Fault{Kind: wmapisim.FaultStatusError, Cmd: client.CmdUpdatePassword, Code: 132, Msg: "update password failed"}, false},
+		{"verification fails", wmapisim.Fault{Kind: wmapisim.FaultStatusError, Cmd: client.CmdStatus, Code: 14, Msg: "invalid cmd"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+			if err := sim.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer sim.Close()
+			sim.InjectFault(tt.fault)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			store := wmapi.NewMemoryCredentialStore(map[string]string{sim.Host(): "admin"})
+			rotation := &wmapi.PasswordRotation{Store: store, Port: sim.Port()}
+
+			res := rotation.Run(ctx, sim.Host())[0]
+			if res.Err == nil || res.Verified {
+				t.Fatalf("result = %+v, want an unverified failure", res)
+			}
+			stored, _ := store.Password(ctx, sim.Host())
+			if tt.stored {
+				if !res.Changed || !res.Stored || !errors.Is(res.Err, wmapi.ErrPasswordUnverified) {
+					t.Errorf("result = %+v, want changed and stored with ErrPasswordUnverified", res)
+				}
+				if stored != sim.Password() || stored != res.Password {
+					t.Errorf("stored %q, simulator has %q, result has %q", stored, sim.Password(), res.Password)
+				}
+				return
+			}
+			if res.Changed || res.Stored || res.Password != "" {
+				t.Errorf("result = %+v, want no change and no password", res)
+			}
+			if stored != "admin" || sim.Password() != "admin" {
+				t.Errorf("stored %q, simulator has %q, want the old password in both", stored, sim.Password())
+			}
+		})
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	for range 1000 {
+		pwd, err := wmapi.GeneratePassword()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pwd) != client.MaxPasswordLen || !client.ValidPassword(pwd) {
+			t.Fatalf("GeneratePassword() = %q, not a valid password of the maximum length", pwd)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestSetPhaseDeadlineCancelled cancels ctx after the phase deadline was computed but before it
+// was set, when the expired deadline of watchContext would be overwritten.
+func TestSetPhaseDeadlineCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var deadline time.Time
+	setPhaseDeadline(ctx, func(d time.Time) error {
+		cancel()
+		deadline = d
+		return nil
+	}, time.Minute)
+	if !deadline.Before(time.Now()) {
+		t.Errorf("deadline = %v after cancellation, want it expired", deadline)
+	}
+}
+
+// TestDeadlineConnExpiredSticky lets a read deadline of a deadlineConn fire and then clears it, as
+// watchContext does when a request ends, and checks that reads still report the timeout.
+func TestDeadlineConnExpiredSticky(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := &deadlineConn{Conn: client}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Read past the deadline = %v, want os.ErrDeadlineExceeded", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+	conn.SetDeadline(time.Now().Add(time.Minute))
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Read after clearing an expired deadline = %v, want os.ErrDeadlineExceeded", err)
+	}
+
+	// An unexpired deadline can still be moved.
+	client, server = net.Pipe()
+	defer server.Close()
+	conn = &deadlineConn{Conn: client}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	conn.SetReadDeadline(time.Time{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		server.Write([]byte("x"))
+	}()
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		t.Errorf("Read after clearing a pending deadline = %v, want nil", err)
+	}
+}
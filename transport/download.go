@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Downloader is implemented by protocols that can stream a file the miner sends after the reply to
+// a write command, as download_logs does. WhatsminerAPIV3 does not implement it.
+type Downloader interface {
+	ExecCommandDownload(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any, dst io.Writer) (int64, error)
+}
+
+var _ Downloader = (*WhatsminerAPI)(nil)
+
+// ExecCommandDownload sends the write command cmd and copies the file that follows its reply on
+// the same connection to dst. The reply announces the file size in Msg.logfilelen. The read
+// timeout applies to each read, so large files are not cut off as long as data keeps arriving.
+//
+// It returns the number of bytes written to dst. The command is never retried because dst may
+// already hold part of the file.
+func (w *WhatsminerAPI) ExecCommandDownload(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any, dst io.Writer) (int64, error) {
+	if accessToken.v3 != nil {
+		return 0, fmt.Errorf("%w: %s over API 3.x", ErrUnsupported, cmd)
+	}
+	encCmd, err := sealCommand(ctx, accessToken, cmd, additionalParams)
+	if err != nil {
+		return 0, err
+	}
+
+	var written int64
+	err = w.session(ctx, accessToken.IPAddress, accessToken.Port, func(conn net.Conn, addr string) error {
+		if err := w.writeCommand(ctx, conn, addr, encCmd); err != nil {
+			return err
+		}
+
+		reply, rest, err := w.readReply(ctx, conn, addr)
+		if err != nil {
+			return err
+		}
+		size, err := downloadSize(accessToken, cmd, reply)
+		if err != nil {
+			return err
+		}
+
+		src := &deadlineReader{ctx: ctx, conn: conn, r: rest, timeout: w.readTimeout}
+		written, err = io.CopyN(dst, src, size)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, io.EOF):
+			return &ConnError{Op: "read", Addr: addr, Err: fmt.Errorf("file truncated after %d of %d bytes: %w", written, size, io.ErrUnexpectedEOF)}
+		case src.err != nil:
+			return &ConnError{Op: "read", Addr: addr, Err: ctxErr(ctx, src.err)}
+		}
+		return fmt.Errorf("failed to write download after %d of %d bytes: %w", written, size, err)
+	})
+	return written, err
+}
+
+// downloadSize decrypts the reply to a download command and returns the announced file size.
+func downloadSize(accessToken *WhatsminerAccessToken, cmd string, reply []byte) (int64, error) {
+	accessToken.mu.Lock()
+	plain, err := decryptResponse(accessToken, cmd, reply)
+	accessToken.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		Msg struct {
+			// Firmware reports the length as a string, but accept a number too.
+			LogFileLen json.RawMessage `json:"logfilelen"`
+		} `json:"Msg"`
+	}
+	if err := json.Unmarshal(plain, &resp); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	size, err := strconv.ParseInt(strings.Trim(string(resp.Msg.LogFileLen), `"`), 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("%w: %s did not report a valid logfilelen: %s", ErrInvalidResponse, cmd, resp.Msg.LogFileLen)
+	}
+	return size, nil
+}
+
+// deadlineReader refreshes the read deadline of conn before every read from r and remembers the
+// last error other than io.EOF.
+type deadlineReader struct {
+	ctx     context.Context
+	conn    net.Conn
+	r       io.Reader
+	timeout time.Duration
+	err     error
+}
+
+func (d *deadlineReader) Read(p []byte) (int, error) {
+	setPhaseDeadline(d.ctx, d.conn.SetReadDeadline, d.timeout)
+	n, err := d.r.Read(p)
+	if err != nil && err != io.EOF {
+		d.err = err
+	}
+	return n, err
+}
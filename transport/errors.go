@@ -0,0 +1,143 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrTooManyConnections is matched when the miner refuses a request with "over max connect".
+	ErrTooManyConnections = errors.New("miner has too many open connections")
+	// ErrTokenExpired is matched when the miner rejects the token sign of a write command.
+	ErrTokenExpired = errors.New("token expired or invalid")
+	// ErrWriteAccessDisabled is returned when a write command is sent with a token that has no
+	// admin password or no usable cipher.
+	ErrWriteAccessDisabled = errors.New("write access is not enabled")
+	// ErrInvalidPassword is matched when the miner cannot decrypt a write command, which happens
+	// when the token was derived from the wrong admin password.
+	ErrInvalidPassword = errors.New("invalid admin password")
+	// ErrPermissionDenied is matched when the miner refuses a command for the given credentials.
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrInvalidCommand is matched when the miner does not know the command or its parameters.
+	ErrInvalidCommand = errors.New("invalid command or parameters")
+	// ErrDecrypt is returned when an encrypted response cannot be decoded. This usually means the
+	// admin password is wrong.
+	ErrDecrypt = errors.New("failed to decrypt response")
+	// ErrInvalidResponse is returned when a response is not the JSON the protocol requires.
+	ErrInvalidResponse = errors.New("invalid response from miner")
+	// ErrResponseTooLarge is returned when a miner response exceeds the configured maximum size.
+	ErrResponseTooLarge = errors.New("response exceeds maximum size")
+	// ErrUnsupported is returned before sending a command the miner's protocol or firmware does not
+	// offer.
+	ErrUnsupported = errors.New("command not supported by this firmware")
+)
+
+// Status codes reported by the miner in the "Code" field.
+const (
+	CodeInvalidCommand   = 14
+	CodeInvalidJSON      = 23
+	CodePermissionDenied = 45
+	CodeCommandOK        = 131
+	CodeCommandError     = 132
+	CodeTokenOK          = 134
+	CodeCheckTokenError  = 135
+	CodeTokenOverMax     = 136
+	CodeBase64Error      = 137
+)
+
+// Status codes reported by API 3.x firmware in the "code" field.
+const (
+	CodeV3OK               = 0
+	CodeV3Failed           = -1
+	CodeV3InvalidCommand   = -2
+	CodeV3InvalidJSON      = -3
+	CodeV3PermissionDenied = -4
+)
+
+// MinerError is a STATUS "E" reply from the miner.
+type MinerError struct {
+	Cmd  string
+	Code int
+	Msg  string
+	When time.Time
+	// Encrypted is set for replies to write commands.
+	Encrypted bool
+}
+
+func (e *MinerError) Error() string {
+	if e.Cmd == "" {
+		return fmt.Sprintf("miner API error: %s (code %d)", e.Msg, e.Code)
+	}
+	return fmt.Sprintf("miner API error for %s: %s (code %d)", e.Cmd, e.Msg, e.Code)
+}
+
+// Is lets errors.Is match a MinerError against the sentinel errors of this package.
+func (e *MinerError) Is(target error) bool {
+	switch target {
+	case ErrTooManyConnections:
+		return e.Msg == "over max connect" || e.Code == CodeTokenOverMax
+	case ErrTokenExpired:
+		return e.Code == CodeCheckTokenError
+	case ErrPermissionDenied:
+		return e.Code == CodePermissionDenied || e.Code == CodeV3PermissionDenied
+	case ErrInvalidCommand:
+		return e.Code == CodeInvalidCommand || e.Code == CodeV3InvalidCommand
+	case ErrInvalidPassword:
+		// The firmware cannot tell a bad key from malformed JSON once the payload is encrypted.
+		return e.Encrypted && e.Code == CodeInvalidJSON
+	}
+	return false
+}
+
+// ConnError is a network failure while talking to the miner. Op is "dial", "write" or "read", so
+// callers can tell whether the request may have reached the miner.
+type ConnError struct {
+	Op   string
+	Addr string
+	Err  error
+}
+
+func (e *ConnError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Addr, e.Err)
+}
+
+func (e *ConnError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the failure was caused by a deadline.
+func (e *ConnError) Timeout() bool {
+	var t interface{ Timeout() bool }
+	return errors.As(e.Err, &t) && t.Timeout()
+}
+
+// statusError returns a *MinerError if result is a STATUS "E" reply. Both the flat form used by
+// the newer commands and the list form used by the cgminer-style commands are recognised.
+func statusError(cmd string, result map[string]any) *MinerError {
+	status := result
+	if list, ok := result["STATUS"].([]any); ok && len(list) > 0 {
+		if first, ok := list[0].(map[string]any); ok {
+			status = first
+		}
+	}
+
+	if s, _ := status["STATUS"].(string); s != "E" {
+		if msg, _ := status["Msg"].(string); msg != "over max connect" {
+			return nil
+		}
+	}
+
+	e := &MinerError{Cmd: cmd}
+	e.Msg, _ = status["Msg"].(string)
+	if e.Msg == "" {
+		e.Msg = "unknown miner API error"
+	}
+	if code, ok := status["Code"].(float64); ok {
+		e.Code = int(code)
+	}
+	if when, ok := status["When"].(float64); ok && when > 0 {
+		e.When = time.Unix(int64(when), 0)
+	}
+	return e
+}
@@ -0,0 +1,90 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestStatusErrorSentinels decodes STATUS "E" replies in the flat and the list form and checks
+// which sentinel errors each one matches.
+func TestStatusErrorSentinels(t *testing.T) {
+	sentinels := []error{ErrTooManyConnections, ErrTokenExpired, ErrPermissionDenied, ErrInvalidCommand, ErrInvalidPassword}
+
+	tests := []struct {
+		name      string
+		encrypted bool
+		status    string // STATUS object without the enclosing braces
+		wantCode  int
+		wantMsg   string
+		// want is the sentinel the error matches, or nil if it matches none.
+		want error
+	}{
+		{"over max connect message", false, `"STATUS":"E","Code":0,"Msg":"over max connect"`, 0, "over max connect", ErrTooManyConnections},
+		{"over max connect without STATUS E", false, `"STATUS":"S","Code":0,"Msg":"over max connect"`, 0, "over max connect", ErrTooManyConnections},
+		{"token over max", false, `"STATUS":"E","Code":136,"Msg":"token over max"`, 136, "token over max", ErrTooManyConnections},
+		{"check token error", true, `"STATUS":"E","Code":135,"Msg":"check token err"`, 135, "check token err", ErrTokenExpired},
+		{"permission denied", false, `"STATUS":"E","Code":45,"Msg":"permission denied"`, 45, "permission denied", ErrPermissionDenied},
+		{"v3 permission denied", false, `"STATUS":"E","Code":-4,"Msg":"permission denied"`, -4, "permission denied", ErrPermissionDenied},
+		{"invalid command", false, `"STATUS":"E","Code":14,"Msg":"invalid cmd"`, 14, "invalid cmd", ErrInvalidCommand},
+		{"v3 invalid command", false, `"STATUS":"E","Code":-2,"Msg":"invalid cmd"`, -2, "invalid cmd", ErrInvalidCommand},
+		{"invalid json when encrypted", true, `"STATUS":"E","Code":23,"Msg":"invalid json"`, 23, "invalid json", ErrInvalidPassword},
+		{"invalid json in plaintext", false, `"STATUS":"E","Code":23,"Msg":"invalid json"`, 23, "invalid json", nil},
+		{"other command error", false, `"STATUS":"E","Code":132,"Msg":"command error"`, 132, "command error", nil},
+		{"missing message", false, `"STATUS":"E","Code":132`, 132, "unknown miner API error", nil},
+	}
+
+	shapes := []struct {
+		name string
+		wrap func(status string) string
+	}{
+		{"flat", func(status string) string { return `{` + status + `,"When":1700000000}` }},
+		{"list", func(status string) string { return `{"STATUS":[{` + status + `,"When":1700000000}],"SUMMARY":[]}` }},
+	}
+
+	for _, shape := range shapes {
+		for _, tt := range tests {
+			t.Run(shape.name+"/"+tt.name, func(t *testing.T) {
+				var result map[string]any
+				if err := json.Unmarshal([]byte(shape.wrap(tt.status)), &result); err != nil {
+					t.Fatal(err)
+				}
+				minerErr := statusError("summary", result)
+				if minerErr == nil {
+					t.Fatal("statusError = nil, want a *MinerError")
+				}
+				minerErr.Encrypted = tt.encrypted
+
+				if minerErr.Code != tt.wantCode || minerErr.Msg != tt.wantMsg || minerErr.Cmd != "summary" {
+					t.Errorf("got code %d msg %q cmd %q, want code %d msg %q cmd summary", minerErr.Code, minerErr.Msg, minerErr.Cmd, tt.wantCode, tt.wantMsg)
+				}
+				if minerErr.When.Unix() != 1700000000 {
+					t.Errorf("When = %v, want 1700000000", minerErr.When.Unix())
+				}
+				var err error = minerErr
+				for _, sentinel := range sentinels {
+					if got, want := errors.Is(err, sentinel), sentinel == tt.want; got != want {
+						t.Errorf("errors.Is(err, %q) = %v, want %v", sentinel, got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestStatusErrorSuccess checks that successful replies in either form are not errors.
+func TestStatusErrorSuccess(t *testing.T) {
+	for _, resp := range []string{
+		`{"STATUS":"S","When":1700000000,"Code":131,"Msg":"API command OK"}`,
+		`{"STATUS":[{"STATUS":"S","When":1700000000,"Code":11,"Msg":"Summary"}],"SUMMARY":[]}`,
+		`{"enc":"AAAA"}`,
+	} {
+		var result map[string]any
+		if err := json.Unmarshal([]byte(resp), &result); err != nil {
+			t.Fatal(err)
+		}
+		if err := statusError("summary", result); err != nil {
+			t.Errorf("statusError(%s) = %v, want nil", resp, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"bytes"
+	"strings"
+)
+
+// repairJSON rewrites the non-standard JSON some firmware emits into valid JSON without touching
+// string contents:
+//
+//   - bare inf, -inf, infinity and nan literals become the strings "Inf", "-Inf" and "NaN", so
+//     they stay distinguishable from real numbers;
+//   - trailing commas before a closing bracket are dropped;
+//   - unquoted object keys are quoted.
+//
+// Input that is already valid JSON is returned unchanged.
+func repairJSON(resp []byte) []byte {
+	out := make([]byte, 0, len(resp)+16)
+	// commaAt is the position in out of a comma that has not been followed by a value yet.
+	commaAt := -1
+
+	for i := 0; i < len(resp); {
+		c := resp[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			out = append(out, c)
+			i++
+		case c == ',':
+			// A doubled comma is an empty element; keep only the first.
+			if commaAt < 0 {
+				commaAt = len(out)
+				out = append(out, c)
+			}
+			i++
+		case c == '}' || c == ']':
+			if commaAt >= 0 {
+				out = append(out[:commaAt], out[commaAt+1:]...)
+				commaAt = -1
+			}
+			out = append(out, c)
+			i++
+		case c == '"':
+			commaAt = -1
+			end := stringEnd(resp, i)
+			out = append(out, resp[i:end]...)
+			i = end
+		case isWordByte(c):
+			commaAt = -1
+			end := i + 1
+			for end < len(resp) && isWordByte(resp[end]) {
+				end++
+			}
+			out = appendWord(out, resp[i:end], nextNonSpace(resp, end) == ':')
+			i = end
+		default:
+			commaAt = -1
+			out = append(out, c)
+			i++
+		}
+	}
+	return out
+}
+
+// appendWord emits a bare token: a key is quoted, a non-standard number is replaced and everything
+// else is kept as is.
+func appendWord(out, word []byte, isKey bool) []byte {
+	if isKey {
+		out = append(out, '"')
+		out = append(out, word...)
+		return append(out, '"')
+	}
+
+	switch strings.ToLower(string(word)) {
+	case "inf", "+inf", "infinity", "+infinity":
+		return append(out, `"Inf"`...)
+	case "-inf", "-infinity":
+		return append(out, `"-Inf"`...)
+	case "nan", "-nan", "+nan":
+		return append(out, `"NaN"`...)
+	}
+	return append(out, word...)
+}
+
+// stringEnd returns the index just past the string literal starting at resp[start].
+func stringEnd(resp []byte, start int) int {
+	for i := start + 1; i < len(resp); i++ {
+		switch resp[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(resp)
+}
+
+// nextNonSpace returns the first non-whitespace byte at or after i, or 0 at the end of input.
+func nextNonSpace(resp []byte, i int) byte {
+	rest := bytes.TrimLeft(resp[i:], " \t\r\n")
+	if len(rest) == 0 {
+		return 0
+	}
+	return rest[0]
+}
+
+// isWordByte reports whether c can be part of a bare token: a number, a literal or an unquoted key.
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '-' || c == '+' || c == '.' || c == '%'
+}
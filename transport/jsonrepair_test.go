@@ -0,0 +1,41 @@
+package transport
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"valid input", `{"a":1.5,"b":[true,false,null],"c":"x"}`, `{"a":1.5,"b":[true,false,null],"c":"x"}`},
+		{"inf", `{"Power Rate":inf}`, `{"Power Rate":"Inf"}`},
+		{"negative inf", `{"a":-inf}`, `{"a":"-Inf"}`},
+		{"infinity", `{"a":Infinity,"b":-infinity}`, `{"a":"Inf","b":"-Inf"}`},
+		{"nan", `{"a":nan,"b":NaN,"c":-nan}`, `{"a":"NaN","b":"NaN","c":"NaN"}`},
+		{"non-standard numbers in a list", `[inf, nan, 1]`, `["Inf", "NaN", 1]`},
+		{"literals inside strings", `{"URL":"stratum+tcp://btc.infinity.example:3333","Msg":"info","x":"nan inf"}`, `{"URL":"stratum+tcp://btc.infinity.example:3333","Msg":"info","x":"nan inf"}`},
+		{"unquoted keys", `{Code:131, power_mode:"Normal", inf:nan}`, `{"Code":131, "power_mode":"Normal", "inf":"NaN"}`},
+		{"trailing comma in object", `{"a":1,}`, `{"a":1}`},
+		{"trailing comma in list", `{"a":[1,2, ]}`, `{"a":[1,2 ]}`},
+		{"doubled comma", `{"a":1,,"b":2}`, `{"a":1,"b":2}`},
+		{"doubled comma in list", `[1,,2,,]`, `[1,2]`},
+		{"commas inside strings", `{"a":",}","b":",,"}`, `{"a":",}","b":",,"}`},
+		{"escaped quotes", `{"a":"say \"inf\", nan","b":inf}`, `{"a":"say \"inf\", nan","b":"Inf"}`},
+		{"escaped backslash before closing quote", `{"a":"C:\\","b":nan}`, `{"a":"C:\\","b":"NaN"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(repairJSON([]byte(tt.in)))
+			if got != tt.want {
+				t.Errorf("repairJSON(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("repairJSON(%s) = %s is not valid JSON", tt.in, got)
+			}
+		})
+	}
+}
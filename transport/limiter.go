@@ -0,0 +1,148 @@
+package transport
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// DefaultMaxConnsPerHost is the number of concurrent connections DefaultHostLimiter allows per
+// miner. It is kept low because the firmware refuses with "over max connect" well before most
+// clients would notice.
+const DefaultMaxConnsPerHost = 3
+
+// DefaultHostLimiter is shared by every WhatsminerAPI that has not been given its own limiter, so
+// all tokens and middlewares in a process that talk to the same miner share its connection budget.
+var DefaultHostLimiter = NewHostLimiter(DefaultMaxConnsPerHost)
+
+// WithHostLimiter makes the API use l instead of DefaultHostLimiter. APIs that should share a
+// budget must share a limiter.
+func WithHostLimiter(l *HostLimiter) Option {
+	return func(w *WhatsminerAPI) {
+		w.limiter = l
+	}
+}
+
+// HostLimiter bounds the number of concurrent connections per miner address. Callers beyond the
+// limit wait in FIFO order until a connection is released or their context is done.
+type HostLimiter struct {
+	mu    sync.Mutex
+	limit int
+	hosts map[string]*hostSlots
+}
+
+type hostSlots struct {
+	active  int
+	waiters []chan struct{}
+}
+
+// NewHostLimiter returns a limiter allowing limit concurrent connections per address. A limit of
+// zero or less means unlimited.
+func NewHostLimiter(limit int) *HostLimiter {
+	return &HostLimiter{limit: limit, hosts: make(map[string]*hostSlots)}
+}
+
+// Limit returns the current per-address limit.
+func (l *HostLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// SetLimit changes the per-address limit. Raising it admits queued callers immediately; lowering it
+// takes effect as connections are released.
+func (l *HostLimiter) SetLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	for _, h := range l.hosts {
+		for len(h.waiters) > 0 && l.hasRoom(h) {
+			l.grant(h)
+		}
+	}
+}
+
+// Acquire waits for a connection slot for addr. The returned function releases the slot and must
+// be called exactly once.
+func (l *HostLimiter) Acquire(ctx context.Context, addr string) (release func(), err error) {
+	l.mu.Lock()
+	h, ok := l.hosts[addr]
+	if !ok {
+		h = &hostSlots{}
+		l.hosts[addr] = h
+	}
+
+	if len(h.waiters) == 0 && l.hasRoom(h) {
+		h.active++
+		l.mu.Unlock()
+		return l.releaser(addr, h), nil
+	}
+
+	ready := make(chan struct{})
+	h.waiters = append(h.waiters, ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return l.releaser(addr, h), nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		if i := slices.Index(h.waiters, ready); i >= 0 {
+			h.waiters = slices.Delete(h.waiters, i, i+1)
+			l.cleanup(addr, h)
+			l.mu.Unlock()
+		} else {
+			// The slot was granted while we were giving up; hand it on.
+			l.mu.Unlock()
+			l.release(addr, h)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Active returns the number of connections currently held for addr.
+func (l *HostLimiter) Active(addr string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if h, ok := l.hosts[addr]; ok {
+		return h.active
+	}
+	return 0
+}
+
+func (l *HostLimiter) releaser(addr string, h *hostSlots) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.release(addr, h) })
+	}
+}
+
+func (l *HostLimiter) release(addr string, h *hostSlots) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h.active--
+	for len(h.waiters) > 0 && l.hasRoom(h) {
+		l.grant(h)
+	}
+	l.cleanup(addr, h)
+}
+
+// hasRoom reports whether another connection may be opened. The caller must hold the mutex.
+func (l *HostLimiter) hasRoom(h *hostSlots) bool {
+	return l.limit <= 0 || h.active < l.limit
+}
+
+// grant admits the longest-waiting caller. The caller must hold the mutex.
+func (l *HostLimiter) grant(h *hostSlots) {
+	ready := h.waiters[0]
+	h.waiters = h.waiters[1:]
+	h.active++
+	close(ready)
+}
+
+// cleanup forgets idle addresses. The caller must hold the mutex.
+func (l *HostLimiter) cleanup(addr string, h *hostSlots) {
+	if h.active == 0 && len(h.waiters) == 0 && l.hosts[addr] == h {
+		delete(l.hosts, addr)
+	}
+}
@@ -0,0 +1,225 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitQueued waits until n callers are queued for addr.
+func waitQueued(t *testing.T, l *HostLimiter, addr string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		l.mu.Lock()
+		queued := 0
+		if h, ok := l.hosts[addr]; ok {
+			queued = len(h.waiters)
+		}
+		l.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d callers queued for %s, want %d", queued, addr, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// checkIdle fails if l still tracks any address.
+func checkIdle(t *testing.T, l *HostLimiter) {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.hosts) != 0 {
+		t.Errorf("limiter still tracks %d addresses after every slot was released", len(l.hosts))
+	}
+}
+
+func TestHostLimiterFIFO(t *testing.T) {
+	const addr = "10.0.0.1:4028"
+	l := NewHostLimiter(1)
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order := make(chan int, 5)
+	for i := range 5 {
+		go func() {
+			r, err := l.Acquire(ctx, addr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			order <- i
+			r()
+		}()
+		waitQueued(t, l, addr, i+1)
+	}
+
+	release()
+	var got []int
+	for range 5 {
+		got = append(got, <-order)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("slots granted in order %v, want %v", got, want)
+	}
+	checkIdle(t, l)
+}
+
+func TestHostLimiterCancelWhileQueued(t *testing.T) {
+	const addr = "10.0.0.1:4028"
+	l := NewHostLimiter(1)
+
+	release, err := l.Acquire(context.Background(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := l.Acquire(ctx, addr)
+		cancelled <- err
+	}()
+	waitQueued(t, l, addr, 1)
+
+	next := make(chan func(), 1)
+	go func() {
+		r, err := l.Acquire(context.Background(), addr)
+		if err != nil {
+			t.Error(err)
+		}
+		next <- r
+	}()
+	waitQueued(t, l, addr, 2)
+
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Acquire returned %v, want context.Canceled", err)
+	}
+	waitQueued(t, l, addr, 1)
+
+	release()
+	select {
+	case r := <-next:
+		if got := l.Active(addr); got != 1 {
+			t.Errorf("Active = %d after the next waiter was admitted, want 1", got)
+		}
+		r()
+	case <-time.After(5 * time.Second):
+		t.Fatal("next waiter was not woken after the slot was released")
+	}
+	checkIdle(t, l)
+}
+
+// TestHostLimiterCancelRacesGrant releases a slot and cancels the waiter it is handed to at the
+// same time. Whichever wins, the slot must end up with the waiter behind it.
+func TestHostLimiterCancelRacesGrant(t *testing.T) {
+	const addr = "10.0.0.1:4028"
+	l := NewHostLimiter(1)
+
+	for range 200 {
+		release, err := l.Acquire(context.Background(), addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		first := make(chan error, 1)
+		go func() {
+			r, err := l.Acquire(ctx, addr)
+			if err == nil {
+				r()
+			}
+			first <- err
+		}()
+		waitQueued(t, l, addr, 1)
+
+		second := make(chan func(), 1)
+		go func() {
+			r, _ := l.Acquire(context.Background(), addr)
+			second <- r
+		}()
+		waitQueued(t, l, addr, 2)
+
+		cancel()
+		release()
+		<-first
+		select {
+		case r := <-second:
+			r()
+		case <-time.After(5 * time.Second):
+			t.Fatal("slot leaked: the waiter behind the cancelled one was never admitted")
+		}
+		checkIdle(t, l)
+	}
+}
+
+// TestHostLimiterSharedByTokens sends commands through two tokens for the same miner and one for
+// another miner, and checks that only the first two share a slot.
+func TestHostLimiterSharedByTokens(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		dialing = make(map[string]int)
+		maxSeen = make(map[string]int)
+	)
+	unblock := make(chan struct{})
+	dialer := DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		mu.Lock()
+		dialing[address]++
+		maxSeen[address] = max(maxSeen[address], dialing[address])
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			dialing[address]--
+			mu.Unlock()
+		}()
+		select {
+		case <-unblock:
+		case <-ctx.Done():
+		}
+		return nil, errors.New("miner unreachable")
+	})
+
+	l := NewHostLimiter(1)
+	api := NewWhatsminerAPI(WithHostLimiter(l), WithDialer(dialer))
+	tokens := []*WhatsminerAccessToken{
+		{IPAddress: "10.0.0.1", Port: 4028},
+		{IPAddress: "10.0.0.1", Port: 4028},
+		{IPAddress: "10.0.0.2", Port: 4028},
+	}
+
+	var wg sync.WaitGroup
+	for _, token := range tokens {
+		wg.Go(func() {
+			api.GetReadOnlyRaw(context.Background(), token, "summary", nil)
+		})
+	}
+	waitQueued(t, l, "10.0.0.1:4028", 1)
+	// The other miner has its own budget, so its command is not held up by the queue.
+	for deadline := time.Now().Add(5 * time.Second); l.Active("10.0.0.2:4028") != 1; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the command to the other miner never got a slot")
+		}
+	}
+	close(unblock)
+	wg.Wait()
+
+	if got := maxSeen["10.0.0.1:4028"]; got != 1 {
+		t.Errorf("%d concurrent dials to the shared miner, want 1", got)
+	}
+	if got := maxSeen["10.0.0.2:4028"]; got != 1 {
+		t.Errorf("%d concurrent dials to the other miner, want 1", got)
+	}
+	checkIdle(t, l)
+}
@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+const (
+	// DefaultDialTimeout is used when no dial timeout has been configured.
+	DefaultDialTimeout = 5 * time.Second
+	// DefaultMaxResponseSize bounds how many bytes are read from a single miner response.
+	DefaultMaxResponseSize = 4 << 20
+)
+
+// Dialer opens connections to a miner. *net.Dialer satisfies this interface, as do proxy and
+// in-memory dialers used for tunnelling or testing.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// DialerFunc adapts an ordinary function to the Dialer interface.
+type DialerFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// DialContext calls f(ctx, network, address).
+func (f DialerFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
+
+// Option configures a WhatsminerAPI.
+type Option func(*WhatsminerAPI)
+
+// WithDialer replaces the default TCP dialer. When set, WithLocalAddr is ignored.
+func WithDialer(d Dialer) Option {
+	return func(w *WhatsminerAPI) {
+		w.dialer = d
+	}
+}
+
+// WithDialTimeout sets how long to wait for a connection to be established.
+func WithDialTimeout(d time.Duration) Option {
+	return func(w *WhatsminerAPI) {
+		w.dialTimeout = d
+	}
+}
+
+// WithReadTimeout sets how long to wait for the miner to send its full response.
+// Zero means no limit other than the request context.
+func WithReadTimeout(d time.Duration) Option {
+	return func(w *WhatsminerAPI) {
+		w.readTimeout = d
+	}
+}
+
+// WithWriteTimeout sets how long to wait for a request to be written to the miner.
+// Zero means no limit other than the request context.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(w *WhatsminerAPI) {
+		w.writeTimeout = d
+	}
+}
+
+// WithMaxResponseSize limits the size of a single response. A negative value disables the limit,
+// except that API 3.x messages are never larger than 256 MiB.
+func WithMaxResponseSize(n int64) Option {
+	return func(w *WhatsminerAPI) {
+		w.maxResponseSize = n
+	}
+}
+
+// WithLocalAddr binds outgoing connections to the given source address, e.g. a specific NIC
+// on a multi-homed host. It only applies to the default dialer.
+func WithLocalAddr(addr net.Addr) Option {
+	return func(w *WhatsminerAPI) {
+		w.localAddr = addr
+	}
+}
@@ -0,0 +1,223 @@
+package transport_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// pipeListener is an in-memory net.Listener whose connections are created by its dialer.
+type pipeListener struct {
+	conns     chan net.Conn
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu     sync.Mutex
+	dialed []string
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(10, 1, 2, 3), Port: 4028}
+}
+
+// dialer returns a Dialer that connects to l in memory and records the addresses dialed.
+func (l *pipeListener) dialer() transport.Dialer {
+	return transport.DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		l.mu.Lock()
+		l.dialed = append(l.dialed, address)
+		l.mu.Unlock()
+
+		client, server := net.Pipe()
+		select {
+		case l.conns <- server:
+			return client, nil
+		case <-l.closed:
+			return nil, net.ErrClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+}
+
+func TestWithDialerInMemory(t *testing.T) {
+	l := newPipeListener()
+	sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+	go sim.Serve(l)
+	defer sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// WithLocalAddr only applies to the default dialer, so an address this host does not have
+	// must not matter.
+	api := transport.NewWhatsminerAPI(
+		transport.WithDialer(l.dialer()),
+		transport.WithLocalAddr(&net.TCPAddr{IP: net.IPv4(192, 0, 2, 1)}),
+	)
+	token, err := api.NewAccessToken(ctx, "10.1.2.3", 4028, sim.Password())
+	if err != nil {
+		t.Fatalf("NewAccessToken: %v", err)
+	}
+	defer token.Close()
+
+	if _, err := api.GetReadOnlyRaw(ctx, token, "summary", nil); err != nil {
+		t.Errorf("read: %v", err)
+	}
+	if _, err := api.ExecCommandRaw(ctx, token, "set_low_power", nil); err != nil {
+		t.Errorf("write: %v", err)
+	}
+	if got := sim.State().PowerMode; got != "Low" {
+		t.Errorf("power mode = %s, want Low", got)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, addr := range l.dialed {
+		if addr != "10.1.2.3:4028" {
+			t.Errorf("dialed %s, want 10.1.2.3:4028", addr)
+		}
+	}
+}
+
+func TestWithLocalAddr(t *testing.T) {
+	sim := startSim(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	token := &transport.WhatsminerAccessToken{IPAddress: sim.Host(), Port: sim.Port()}
+
+	api := transport.NewWhatsminerAPI(transport.WithLocalAddr(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}))
+	if _, err := api.GetReadOnlyRaw(ctx, token, "summary", nil); err != nil {
+		t.Errorf("read from a local address: %v", err)
+	}
+
+	// 192.0.2.1 is reserved for documentation, so binding to it fails before anything is sent.
+	api = transport.NewWhatsminerAPI(transport.WithLocalAddr(&net.TCPAddr{IP: net.IPv4(192, 0, 2, 1)}))
+	_, err := api.GetReadOnlyRaw(ctx, token, "summary", nil)
+	var connErr *transport.ConnError
+	if !errors.As(err, &connErr) || connErr.Op != "dial" {
+		t.Errorf("read from an address this host does not have = %v, want a dial ConnError", err)
+	}
+	if got := sim.Received("summary"); got != 1 {
+		t.Errorf("miner received summary %d times, want 1", got)
+	}
+}
+
+func TestWithMaxResponseSize(t *testing.T) {
+	sim := startSim(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	token := &transport.WhatsminerAccessToken{IPAddress: sim.Host(), Port: sim.Port()}
+
+	tests := []struct {
+		name    string
+		limit   int64
+		wantErr bool
+	}{
+		{"below the response size", 64, true},
+		{"default", 0, false},
+		{"disabled", -1, false},
+	}
+	for _, tt := range tests {
+		api := transport.NewWhatsminerAPI(transport.WithMaxResponseSize(tt.limit))
+		_, err := api.GetReadOnlyRaw(ctx, token, "summary", nil)
+		if tt.wantErr != errors.Is(err, transport.ErrResponseTooLarge) || !tt.wantErr && err != nil {
+			t.Errorf("%s: GetReadOnlyRaw = %v, want ErrResponseTooLarge %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestWithMaxResponseSizeDownload checks that the reply announcing a download obeys the
+// configured limit rather than the default one.
+func TestWithMaxResponseSizeDownload(t *testing.T) {
+	sim := startSim(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		limit   int64
+		wantErr bool
+	}{
+		{"below the reply size", 16, true},
+		{"disabled", -1, false},
+	}
+	token, err := transport.NewWhatsminerAPI().NewAccessToken(ctx, sim.Host(), sim.Port(), sim.Password())
+	if err != nil {
+		t.Fatalf("NewAccessToken: %v", err)
+	}
+	defer token.Close()
+	for _, tt := range tests {
+		api := transport.NewWhatsminerAPI(transport.WithMaxResponseSize(tt.limit))
+		var buf bytes.Buffer
+		_, err := api.ExecCommandDownload(ctx, token, "download_logs", nil, &buf)
+		if tt.wantErr != errors.Is(err, transport.ErrResponseTooLarge) || !tt.wantErr && (err != nil || buf.Len() == 0) {
+			t.Errorf("%s: ExecCommandDownload = %v, want ErrResponseTooLarge %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWithReadTimeout(t *testing.T) {
+	sim := startSim(t)
+	sim.InjectFault(wmapisim.Fault{Kind: wmapisim.FaultSlowLoris, Cmd: "summary", Delay: 20 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	token := &transport.WhatsminerAccessToken{IPAddress: sim.Host(), Port: sim.Port()}
+	api := transport.NewWhatsminerAPI(transport.WithReadTimeout(100 * time.Millisecond))
+
+	start := time.Now()
+	_, err := api.GetReadOnlyRaw(ctx, token, "summary", nil)
+	var connErr *transport.ConnError
+	if !errors.As(err, &connErr) || connErr.Op != "read" || !connErr.Timeout() {
+		t.Fatalf("GetReadOnlyRaw = %v, want a read timeout", err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetReadOnlyRaw = %v, want the read timeout rather than a ctx error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("read timed out after %v, want about 100ms", elapsed)
+	}
+}
+
+func TestWithDialTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	hang := transport.DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	api := transport.NewWhatsminerAPI(transport.WithDialer(hang), transport.WithDialTimeout(50*time.Millisecond))
+	token := &transport.WhatsminerAccessToken{IPAddress: "10.1.2.3", Port: 4028}
+
+	start := time.Now()
+	_, err := api.GetReadOnlyRaw(ctx, token, "summary", nil)
+	var connErr *transport.ConnError
+	if !errors.As(err, &connErr) || connErr.Op != "dial" {
+		t.Errorf("GetReadOnlyRaw = %v, want a dial ConnError", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("dial gave up after %v, want about 50ms", elapsed)
+	}
+}
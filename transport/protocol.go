@@ -0,0 +1,129 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Protocol is implemented by WhatsminerAPI (API 2.x) and WhatsminerAPIV3 (API 3.x). Both accept
+// the 2.x command names and return responses in the 2.x shape, so callers work the same against
+// either firmware generation.
+type Protocol interface {
+	// NewAccessToken creates a token for the miner at ipAddress:port. With an empty admin
+	// password the token can only be used for read commands.
+	NewAccessToken(ctx context.Context, ipAddress string, port int, adminPassword string) (*WhatsminerAccessToken, error)
+	// APIVersion returns the api_ver reported by the miner, e.g. "2.0.5".
+	APIVersion(ctx context.Context, ipAddress string, port int) (string, error)
+	// GetReadOnlyRaw sends a read command and returns the JSON response.
+	GetReadOnlyRaw(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) ([]byte, error)
+	// ExecCommandRaw sends a write command and returns the JSON response.
+	ExecCommandRaw(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) ([]byte, error)
+}
+
+var (
+	_ Protocol = (*WhatsminerAPI)(nil)
+	_ Protocol = (*WhatsminerAPIV3)(nil)
+)
+
+// CommandSupporter is implemented by protocols that can only express some commands.
+// WhatsminerAPIV3 implements it; WhatsminerAPI sends any command as is.
+type CommandSupporter interface {
+	// Supports reports whether cmd can be sent, as a write command if write is set.
+	Supports(cmd string, write bool) bool
+}
+
+var _ CommandSupporter = (*WhatsminerAPIV3)(nil)
+
+// WithV3Port sets the port Detect uses for the API 3.x service. The default is DefaultV3Port.
+func WithV3Port(port int) Option {
+	return func(w *WhatsminerAPI) {
+		w.v3Port = port
+	}
+}
+
+// Version is the Msg of a get_version reply.
+type Version struct {
+	APIVer   string `json:"api_ver"`
+	FwVer    string `json:"fw_ver"`
+	Platform string `json:"platform"`
+	Chip     string `json:"chip"`
+}
+
+// APIVersion sends get_version and returns the api_ver field.
+func (w *WhatsminerAPI) APIVersion(ctx context.Context, ipAddress string, port int) (string, error) {
+	token := &WhatsminerAccessToken{IPAddress: ipAddress, Port: port, api: w}
+	version, err := readVersion(ctx, w, token)
+	if err != nil {
+		return "", err
+	}
+	return version.APIVer, nil
+}
+
+// readVersion sends get_version through p and decodes its Msg, which must report api_ver.
+func readVersion(ctx context.Context, p Protocol, token *WhatsminerAccessToken) (*Version, error) {
+	resp, err := p.GetReadOnlyRaw(ctx, token, "get_version", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var version struct {
+		Msg Version `json:"Msg"`
+	}
+	if err := json.Unmarshal(resp, &version); err != nil || version.Msg.APIVer == "" {
+		return nil, fmt.Errorf("%w: get_version did not report api_ver", ErrInvalidResponse)
+	}
+	return &version.Msg, nil
+}
+
+// Detect finds out which protocol the miner at ipAddress speaks and returns a Protocol configured
+// with opts, together with the port to use for it and the get_version reply it was chosen by. The
+// miner is first asked for its version on port; firmware reporting API 3.x, or not answering on
+// port at all while the API 3.x port does, is handled by WhatsminerAPIV3.
+func Detect(ctx context.Context, ipAddress string, port int, opts ...Option) (Protocol, int, *Version, error) {
+	v2 := NewWhatsminerAPI(opts...)
+	v3 := &WhatsminerAPIV3{conn: v2}
+	v3Port := v2.v3Port
+	if v3Port == 0 {
+		v3Port = DefaultV3Port
+	}
+	v3Version := func(port int) (*Version, error) {
+		return readVersion(ctx, v3, &WhatsminerAccessToken{IPAddress: ipAddress, Port: port, api: v2, v3: v3})
+	}
+
+	if port == v3Port {
+		version, err := v3Version(port)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to detect API version: %w", err)
+		}
+		return v3, port, version, nil
+	}
+
+	version, err := readVersion(ctx, v2, &WhatsminerAccessToken{IPAddress: ipAddress, Port: port, api: v2})
+	if err != nil {
+		var connErr *ConnError
+		if !errors.As(err, &connErr) || connErr.Op != "dial" || ctx.Err() != nil {
+			return nil, 0, nil, fmt.Errorf("failed to detect API version: %w", err)
+		}
+		version, v3Err := v3Version(v3Port)
+		if v3Err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to detect API version: %w", err)
+		}
+		return v3, v3Port, version, nil
+	}
+
+	if apiMajor(version.APIVer) >= 3 {
+		return v3, v3Port, version, nil
+	}
+	return v2, port, version, nil
+}
+
+// apiMajor returns the major number of an api_ver string, or 0 if it cannot be parsed.
+func apiMajor(version string) int {
+	major, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	n, _ := strconv.Atoi(major)
+	return n
+}
@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"golang.org/x/net/proxy"
+)
+
+// SOCKS5Auth holds the optional username/password credentials for a SOCKS5 proxy.
+type SOCKS5Auth struct {
+	User     string
+	Password string
+}
+
+// NewSOCKS5Dialer returns a Dialer that reaches miners through the SOCKS5 proxy at proxyAddr,
+// e.g. a site gateway. forward is used to reach the proxy itself; nil means a direct connection.
+func NewSOCKS5Dialer(proxyAddr string, auth *SOCKS5Auth, forward Dialer) (Dialer, error) {
+	var proxyAuth *proxy.Auth
+	if auth != nil {
+		proxyAuth = &proxy.Auth{User: auth.User, Password: auth.Password}
+	}
+
+	var fwd proxy.Dialer = proxy.Direct
+	if forward != nil {
+		fwd = forwardDialer{forward}
+	}
+
+	d, err := proxy.SOCKS5("tcp", proxyAddr, proxyAuth, fwd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SOCKS5 dialer for %s: %w", proxyAddr, err)
+	}
+
+	cd, ok := d.(proxy.ContextDialer)
+	if !ok {
+		return nil, errors.New("SOCKS5 dialer does not support contexts")
+	}
+	return DialerFunc(cd.DialContext), nil
+}
+
+// forwardDialer lets a Dialer be used where golang.org/x/net/proxy expects one.
+type forwardDialer struct {
+	Dialer
+}
+
+func (f forwardDialer) Dial(network, address string) (net.Conn, error) {
+	return f.DialContext(context.Background(), network, address)
+}
@@ -0,0 +1,137 @@
+package transport_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+)
+
+// serveSOCKS5 answers one SOCKS5 CONNECT on conn, requiring user/pass authentication, and then
+// relays the connection to the requested TCP address.
+func serveSOCKS5(conn net.Conn, user, pass string) {
+	defer conn.Close()
+
+	// Greeting: version, method count and methods; username/password (2) is required.
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(conn, hdr); err != nil || hdr[0] != 5 {
+		return
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+	conn.Write([]byte{5, 2})
+
+	// RFC 1929 subnegotiation.
+	readField := func() string {
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(conn, n); err != nil {
+			return ""
+		}
+		b := make([]byte, n[0])
+		io.ReadFull(conn, b)
+		return string(b)
+	}
+	if _, err := io.ReadFull(conn, hdr[:1]); err != nil {
+		return
+	}
+	if readField() != user || readField() != pass {
+		conn.Write([]byte{1, 1})
+		return
+	}
+	conn.Write([]byte{1, 0})
+
+	// Request: version, CONNECT, reserved, address type and address.
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil || req[1] != 1 {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 3:
+		host = readField()
+	default:
+		conn.Write([]byte{5, 8, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+// pipeProxy is a forward Dialer that serves SOCKS5 in memory over net.Pipe.
+func pipeProxy(user, pass string) transport.Dialer {
+	return transport.DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go serveSOCKS5(server, user, pass)
+		return client, nil
+	})
+}
+
+func TestSOCKS5Dialer(t *testing.T) {
+	sim := startSim(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dialer, err := transport.NewSOCKS5Dialer("gateway:1080", &transport.SOCKS5Auth{User: "site", Password: "secret"}, pipeProxy("site", "secret"))
+	if err != nil {
+		t.Fatalf("NewSOCKS5Dialer: %v", err)
+	}
+	api := transport.NewWhatsminerAPI(transport.WithDialer(dialer))
+	token, err := api.NewAccessToken(ctx, sim.Host(), sim.Port(), sim.Password())
+	if err != nil {
+		t.Fatalf("NewAccessToken through SOCKS5: %v", err)
+	}
+	defer token.Close()
+
+	if _, err := api.GetReadOnlyRaw(ctx, token, "summary", nil); err != nil {
+		t.Errorf("read through SOCKS5: %v", err)
+	}
+	if _, err := api.ExecCommandRaw(ctx, token, "set_high_power", nil); err != nil {
+		t.Errorf("write through SOCKS5: %v", err)
+	}
+	if got := sim.State().PowerMode; got != "High" {
+		t.Errorf("power mode = %s, want High", got)
+	}
+}
+
+func TestSOCKS5DialerWrongPassword(t *testing.T) {
+	sim := startSim(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dialer, err := transport.NewSOCKS5Dialer("gateway:1080", &transport.SOCKS5Auth{User: "site", Password: "wrong"}, pipeProxy("site", "secret"))
+	if err != nil {
+		t.Fatalf("NewSOCKS5Dialer: %v", err)
+	}
+	api := transport.NewWhatsminerAPI(transport.WithDialer(dialer))
+	token := &transport.WhatsminerAccessToken{IPAddress: sim.Host(), Port: sim.Port()}
+	_, err = api.GetReadOnlyRaw(ctx, token, "summary", nil)
+	var connErr *transport.ConnError
+	if !errors.As(err, &connErr) || connErr.Op != "dial" {
+		t.Errorf("read through SOCKS5 with a wrong password = %v, want a dial ConnError", err)
+	}
+	if got := sim.Received("summary"); got != 0 {
+		t.Errorf("miner received summary %d times, want 0", got)
+	}
+}
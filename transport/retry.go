@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy controls how WhatsminerAPI retries failed requests. Read commands are retried on any
+// retryable error. Write commands are retried only when the failure shows the miner never executed
+// the command, unless Idempotent reports that sending it twice is harmless.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles on every further attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts.
+	MaxDelay time.Duration
+	// Jitter is the fraction of each delay, between 0 and 1, that is randomised.
+	Jitter float64
+	// Retryable classifies errors. Nil means IsRetryable.
+	Retryable func(err error) bool
+	// Idempotent reports whether a write command may be re-sent after an ambiguous failure.
+	// Nil means the function set with WithIdempotent or, failing that, no write is considered
+	// idempotent. The wmapi package installs client.IsIdempotent, which reads the command registry.
+	Idempotent func(cmd string) bool
+}
+
+// DefaultRetryPolicy is a reasonable policy for polling miners on a LAN.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      0.2,
+}
+
+// WithRetryPolicy enables retries. Without it every request is attempted exactly once.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(w *WhatsminerAPI) {
+		w.retry = p
+	}
+}
+
+// IsRetryable reports whether err is a transient failure: a network error, a refusal because of
+// too many connections, an expired token or a response that could not be parsed.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var connErr *ConnError
+	if errors.As(err, &connErr) {
+		return !errors.Is(err, ErrResponseTooLarge)
+	}
+
+	return errors.Is(err, ErrTooManyConnections) ||
+		errors.Is(err, ErrTokenExpired) ||
+		errors.Is(err, ErrInvalidResponse)
+}
+
+// NotExecuted reports whether err proves the miner did not act on the request: the connection
+// could not be dialed, the miner refused the command, or the command was never sent because the
+// protocol or firmware does not offer it.
+func NotExecuted(err error) bool {
+	if errors.Is(err, ErrUnsupported) {
+		return true
+	}
+	var connErr *ConnError
+	if errors.As(err, &connErr) {
+		return connErr.Op == "dial"
+	}
+	var minerErr *MinerError
+	return errors.As(err, &minerErr)
+}
+
+// WithIdempotent sets how write commands are classified when the retry policy has no Idempotent
+// function of its own. Without it, or with nil, no write is re-sent after an ambiguous failure.
+func WithIdempotent(fn func(cmd string) bool) Option {
+	return func(w *WhatsminerAPI) {
+		w.idempotent = fn
+	}
+}
+
+// shouldRetry decides whether attempt (1-based) may be followed by another one.
+func (p *RetryPolicy) shouldRetry(attempt int, cmd string, write bool, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	if !retryable(err) {
+		return false
+	}
+
+	if !write || NotExecuted(err) {
+		return true
+	}
+	return p.Idempotent != nil && p.Idempotent(cmd)
+}
+
+// delay returns the backoff before attempt+1.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(float64(d) * p.Jitter * rand.Float64())
+	}
+	return d
+}
+
+// withRetry runs fn until it succeeds, the retry policy of w gives up or ctx is done.
+func withRetry[T any](ctx context.Context, w *WhatsminerAPI, cmd string, write bool, fn func() (T, error)) (T, error) {
+	policy := w.retry
+	if policy.Idempotent == nil {
+		policy.Idempotent = w.idempotent
+	}
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil || !policy.shouldRetry(attempt, cmd, write, err) {
+			return result, err
+		}
+
+		timer := time.NewTimer(policy.delay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			var zero T
+			return zero, err
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// startSim starts a simulated miner that is closed when the test ends.
+func startSim(t *testing.T) *wmapisim.Server {
+	t.Helper()
+	sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+	if err := sim.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sim.Close() })
+	return sim
+}
+
+// failFirstDial is a Dialer whose first dial fails without reaching the miner.
+func failFirstDial() transport.Dialer {
+	var dials atomic.Int32
+	return transport.DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		if dials.Add(1) == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, address)
+	})
+}
+
+// TestRetryFaults drives each simulator fault into a read and a write and counts how often the
+// command reached the miner. A write is only re-sent when the failure proves it was not executed,
+// or when it is idempotent.
+func TestRetryFaults(t *testing.T) {
+	policy := transport.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	tests := []struct {
+		name string
+		// fault is injected for the command under test; nil fails the first dial instead.
+		fault *wmapisim.Fault
+		write bool
+		// idempotent classifies the write command.
+		idempotent bool
+		// want is how many times the miner receives the command.
+		want    int
+		wantErr bool
+	}{
+		{"read after reset", &wmapisim.Fault{Kind: wmapisim.FaultReset, Times: 1}, false, false, 2, false},
+		{"read after truncate", &wmapisim.Fault{Kind: wmapisim.FaultTruncate, Times: 1}, false, false, 2, false},
+		{"read after slow loris", &wmapisim.Fault{Kind: wmapisim.FaultSlowLoris, Delay: 50 * time.Millisecond, Times: 1}, false, false, 2, false},
+		{"read after over max connect", &wmapisim.Fault{Kind: wmapisim.FaultOverMaxConnect, Times: 1}, false, false, 2, false},
+		{"read after dial failure", nil, false, false, 1, false},
+
+		{"write after reset", &wmapisim.Fault{Kind: wmapisim.FaultReset, Times: 1}, true, false, 1, true},
+		{"write after truncate", &wmapisim.Fault{Kind: wmapisim.FaultTruncate, Times: 1}, true, false, 1, true},
+		{"write after slow loris", &wmapisim.Fault{Kind: wmapisim.FaultSlowLoris, Delay: 50 * time.Millisecond, Times: 1}, true, false, 1, true},
+		{"write after over max connect", &wmapisim.Fault{Kind: wmapisim.FaultOverMaxConnect, Times: 1}, true, false, 2, false},
+		{"write after status error", &wmapisim.Fault{Kind: wmapisim.FaultStatusError, Code: 136, Msg: "token over max", Times: 1}, true, false, 2, false},
+		{"write after dial failure", nil, true, false, 1, false},
+
+		{"idempotent write after reset", &wmapisim.Fault{Kind: wmapisim.FaultReset, Times: 1}, true, true, 2, false},
+		{"idempotent write after slow loris", &wmapisim.Fault{Kind: wmapisim.FaultSlowLoris, Delay: 50 * time.Millisecond, Times: 1}, true, true, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := startSim(t)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			cmd := "summary"
+			if tt.write {
+				cmd = "set_low_power"
+			}
+			opts := []transport.Option{
+				transport.WithRetryPolicy(policy),
+				transport.WithReadTimeout(200 * time.Millisecond),
+				transport.WithIdempotent(func(string) bool { return tt.idempotent }),
+			}
+			// The token is set up before any fault is injected, so only cmd is affected.
+			token, err := transport.NewWhatsminerAPI(opts...).NewAccessToken(ctx, sim.Host(), sim.Port(), sim.Password())
+			if err != nil {
+				t.Fatalf("NewAccessToken: %v", err)
+			}
+			defer token.Close()
+
+			if tt.fault == nil {
+				opts = append(opts, transport.WithDialer(failFirstDial()))
+			} else {
+				f := *tt.fault
+				f.Cmd = cmd
+				sim.InjectFault(f)
+			}
+			api := transport.NewWhatsminerAPI(opts...)
+
+			if tt.write {
+				_, err = api.ExecCommandRaw(ctx, token, cmd, nil)
+			} else {
+				_, err = api.GetReadOnlyRaw(ctx, token, cmd, nil)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got := sim.Received(cmd); got != tt.want {
+				t.Errorf("miner received %s %d times, want %d", cmd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotExecuted(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"dial failure", &transport.ConnError{Op: "dial", Addr: "10.0.0.1:4028", Err: errors.New("connection refused")}, true},
+		{"read failure", &transport.ConnError{Op: "read", Addr: "10.0.0.1:4028", Err: errors.New("connection reset")}, false},
+		{"write failure", &transport.ConnError{Op: "write", Addr: "10.0.0.1:4028", Err: errors.New("broken pipe")}, false},
+		{"miner error", fmt.Errorf("update_pwd: %w", &transport.MinerError{Code: 132, Msg: "failed"}), true},
+		{"unsupported", fmt.Errorf("%w: update_firmware", transport.ErrUnsupported), true},
+		{"cancelled", context.Canceled, false},
+		{"invalid response", transport.ErrInvalidResponse, false},
+	}
+	for _, tt := range tests {
+		if got := transport.NotExecuted(tt.err); got != tt.want {
+			t.Errorf("%s: NotExecuted(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
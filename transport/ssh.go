@@ -0,0 +1,282 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHDialer reaches miners by forwarding TCP connections through an SSH jump host, the same way
+// `ssh -L` would. One SSH connection is shared by all dials and re-established when it drops.
+type SSHDialer struct {
+	// Addr is the host:port of the SSH server.
+	Addr string
+	// Config holds the SSH credentials and host key policy.
+	Config *ssh.ClientConfig
+	// Forward is used to reach the SSH server; nil means a direct connection.
+	Forward Dialer
+
+	mu      sync.Mutex
+	client  *ssh.Client
+	pending *sshConnect
+}
+
+// NewSSHDialer returns a Dialer that tunnels through the SSH server at addr.
+func NewSSHDialer(addr string, config *ssh.ClientConfig) *SSHDialer {
+	return &SSHDialer{Addr: addr, Config: config}
+}
+
+// DialContext opens a forwarded connection to address through the SSH server.
+func (d *SSHDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	client, err := d.sshClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.DialContext(ctx, network, address)
+	if err == nil {
+		return &deadlineConn{Conn: conn}, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// The SSH connection may have gone away underneath us; reconnect once and retry.
+	d.reset(client)
+	client, err = d.sshClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err = client.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to forward to %s via %s: %w", address, d.Addr, ctxErr(ctx, err))
+	}
+	return &deadlineConn{Conn: conn}, nil
+}
+
+// Close tears down the shared SSH connection.
+func (d *SSHDialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending = nil
+	if d.client == nil {
+		return nil
+	}
+	err := d.client.Close()
+	d.client = nil
+	return err
+}
+
+func (d *SSHDialer) sshClient(ctx context.Context) (*ssh.Client, error) {
+	for {
+		d.mu.Lock()
+		if d.client != nil {
+			client := d.client
+			d.mu.Unlock()
+			return client, nil
+		}
+		if d.Config == nil {
+			d.mu.Unlock()
+			return nil, errors.New("ssh client config is not set")
+		}
+		// Only one dial connects to the SSH server at a time; the others wait for its outcome
+		// without holding the mutex, so they can still give up on their own context.
+		p := d.pending
+		if p == nil {
+			p = &sshConnect{done: make(chan struct{})}
+			d.pending = p
+			d.mu.Unlock()
+			d.connect(ctx, p)
+			return p.client, p.err
+		}
+		d.mu.Unlock()
+
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// A connect abandoned by its own caller says nothing about the SSH server; try again.
+		if p.err != nil && (errors.Is(p.err, context.Canceled) || errors.Is(p.err, context.DeadlineExceeded)) {
+			continue
+		}
+		return p.client, p.err
+	}
+}
+
+// sshConnect is an SSH connection being established. client and err are set before done is
+// closed.
+type sshConnect struct {
+	done   chan struct{}
+	client *ssh.Client
+	err    error
+}
+
+// connect establishes the SSH connection for p and makes it the shared one, unless the dialer was
+// closed in the meantime.
+func (d *SSHDialer) connect(ctx context.Context, p *sshConnect) {
+	client, err := d.handshake(ctx)
+
+	d.mu.Lock()
+	if d.pending == p {
+		d.pending = nil
+		d.client = client
+	} else if err == nil {
+		client.Close()
+		client, err = nil, fmt.Errorf("ssh connection to %s: %w", d.Addr, net.ErrClosed)
+	}
+	d.mu.Unlock()
+
+	p.client, p.err = client, err
+	close(p.done)
+}
+
+// handshake dials the SSH server and authenticates.
+func (d *SSHDialer) handshake(ctx context.Context) (*ssh.Client, error) {
+	var forward Dialer = &net.Dialer{}
+	if d.Forward != nil {
+		forward = d.Forward
+	}
+
+	conn, err := forward.DialContext(ctx, "tcp", d.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh server %s: %w", d.Addr, ctxErr(ctx, err))
+	}
+
+	// The SSH handshake itself does not take a context. Once the watcher has stopped it can no
+	// longer set a deadline, so the one it left behind is cleared only then; if ctx ended
+	// before that, the connection may already carry an expired deadline and is dropped.
+	stop := watchContext(ctx, conn)
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, d.Addr, d.Config)
+	stop()
+	if err == nil && ctx.Err() != nil {
+		sshConn.Close()
+		err = ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", d.Addr, ctxErr(ctx, err))
+	}
+	conn.SetDeadline(time.Time{})
+
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// reset drops client if it is still the shared connection.
+func (d *SSHDialer) reset(client *ssh.Client) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client == client {
+		d.client.Close()
+		d.client = nil
+	}
+}
+
+// deadlineConn emulates deadlines on forwarded SSH channels, which do not support them. A channel
+// cannot interrupt a blocked call, so the connection is closed once a deadline passes; the call
+// then fails with an error wrapping os.ErrDeadlineExceeded, as it would on a TCP connection.
+type deadlineConn struct {
+	net.Conn
+
+	mu    sync.Mutex
+	read  deadline
+	write deadline
+}
+
+// deadline is the state of one direction of a deadlineConn.
+type deadline struct {
+	timer *time.Timer
+	// gen tells a timer that fires late whether its deadline has since been replaced.
+	gen     int
+	expired bool
+}
+
+func (c *deadlineConn) SetDeadline(t time.Time) error {
+	c.set(&c.read, t)
+	c.set(&c.write, t)
+	return nil
+}
+
+func (c *deadlineConn) SetReadDeadline(t time.Time) error {
+	c.set(&c.read, t)
+	return nil
+}
+
+func (c *deadlineConn) SetWriteDeadline(t time.Time) error {
+	c.set(&c.write, t)
+	return nil
+}
+
+func (c *deadlineConn) set(d *deadline, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if d.expired {
+		// The timer has closed the connection, so every later call still fails with the timeout,
+		// whatever deadline is set now.
+		return
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.gen++
+	if t.IsZero() {
+		return
+	}
+	gen := d.gen
+	d.timer = time.AfterFunc(time.Until(t), func() {
+		c.mu.Lock()
+		current := d.gen == gen
+		if current {
+			d.expired = true
+		}
+		c.mu.Unlock()
+		if current {
+			c.Conn.Close()
+		}
+	})
+}
+
+func (c *deadlineConn) expired(d *deadline) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return d.expired
+}
+
+func (c *deadlineConn) Read(p []byte) (int, error) {
+	if c.expired(&c.read) {
+		return 0, c.timeoutError("read")
+	}
+	n, err := c.Conn.Read(p)
+	if err != nil && c.expired(&c.read) {
+		err = c.timeoutError("read")
+	}
+	return n, err
+}
+
+func (c *deadlineConn) Write(p []byte) (int, error) {
+	if c.expired(&c.write) {
+		return 0, c.timeoutError("write")
+	}
+	n, err := c.Conn.Write(p)
+	if err != nil && c.expired(&c.write) {
+		err = c.timeoutError("write")
+	}
+	return n, err
+}
+
+func (c *deadlineConn) timeoutError(op string) error {
+	return &net.OpError{Op: op, Net: "ssh", Source: c.LocalAddr(), Addr: c.RemoteAddr(), Err: os.ErrDeadlineExceeded}
+}
+
+func (c *deadlineConn) Close() error {
+	c.SetDeadline(time.Time{})
+	return c.Conn.Close()
+}
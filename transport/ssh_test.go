@@ -0,0 +1,160 @@
+package transport_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// startSSHServer starts an SSH server that accepts the password "secret" and forwards
+// direct-tcpip channels like `ssh -L` would. It returns its address, its host key and a counter of
+// the SSH connections it accepted.
+func startSSHServer(t *testing.T) (string, ssh.PublicKey, *atomic.Int32) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, pwd []byte) (*ssh.Permissions, error) {
+			if string(pwd) != "secret" {
+				return nil, errors.New("wrong password")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				accepted.Add(1)
+				go ssh.DiscardRequests(reqs)
+				for ch := range chans {
+					go forwardChannel(ch)
+				}
+			}()
+		}
+	}()
+	return l.Addr().String(), signer.PublicKey(), &accepted
+}
+
+// forwardChannel serves a direct-tcpip channel by connecting to its destination.
+func forwardChannel(ch ssh.NewChannel) {
+	var dest struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if ch.ChannelType() != "direct-tcpip" || ssh.Unmarshal(ch.ExtraData(), &dest) != nil {
+		ch.Reject(ssh.UnknownChannelType, "only direct-tcpip is supported")
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(dest.Host, strconv.Itoa(int(dest.Port))))
+	if err != nil {
+		ch.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, reqs, err := ch.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	defer channel.Close()
+	defer target.Close()
+	go io.Copy(target, channel)
+	io.Copy(channel, target)
+}
+
+func TestSSHDialer(t *testing.T) {
+	sim := startSim(t)
+	addr, hostKey, accepted := startSSHServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dialer := transport.NewSSHDialer(addr, &ssh.ClientConfig{
+		User:            "tunnel",
+		Auth:            []ssh.AuthMethod{ssh.Password("secret")},
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+	})
+	defer dialer.Close()
+	api := transport.NewWhatsminerAPI(transport.WithDialer(dialer), transport.WithReadTimeout(200*time.Millisecond))
+	token, err := api.NewAccessToken(ctx, sim.Host(), sim.Port(), sim.Password())
+	if err != nil {
+		t.Fatalf("NewAccessToken through SSH: %v", err)
+	}
+	defer token.Close()
+
+	if _, err := api.GetReadOnlyRaw(ctx, token, "summary", nil); err != nil {
+		t.Errorf("read through SSH: %v", err)
+	}
+	if _, err := api.ExecCommandRaw(ctx, token, "set_low_power", nil); err != nil {
+		t.Errorf("write through SSH: %v", err)
+	}
+	if got := sim.State().PowerMode; got != "Low" {
+		t.Errorf("power mode = %s, want Low", got)
+	}
+
+	// Forwarded channels have no deadlines of their own; a slow miner must still time out.
+	sim.InjectFault(wmapisim.Fault{Kind: wmapisim.FaultSlowLoris, Cmd: "summary", Delay: 20 * time.Millisecond, Times: 1})
+	_, err = api.GetReadOnlyRaw(ctx, token, "summary", nil)
+	var connErr *transport.ConnError
+	if !errors.As(err, &connErr) || connErr.Op != "read" || !connErr.Timeout() {
+		t.Errorf("slow read through SSH = %v, want a read timeout", err)
+	}
+
+	if n := accepted.Load(); n != 1 {
+		t.Errorf("SSH server accepted %d connections, want 1 shared by every dial", n)
+	}
+}
+
+func TestSSHDialerWrongPassword(t *testing.T) {
+	addr, hostKey, _ := startSSHServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dialer := transport.NewSSHDialer(addr, &ssh.ClientConfig{
+		User:            "tunnel",
+		Auth:            []ssh.AuthMethod{ssh.Password("wrong")},
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+	})
+	defer dialer.Close()
+	if conn, err := dialer.DialContext(ctx, "tcp", "127.0.0.1:4028"); err == nil {
+		conn.Close()
+		t.Error("DialContext succeeded with a wrong SSH password")
+	}
+}
@@ -3,6 +3,7 @@
 package transport
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/sha256"
@@ -15,6 +16,7 @@ import (
 	"maps"
 	"net"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -35,20 +37,37 @@ type WhatsminerAccessToken struct {
 
 	mu   sync.Mutex
 	stop chan bool
+	api  *WhatsminerAPI
+	// v3 and salt are set for tokens of API 3.x miners, which sign every write with the salt
+	// instead of holding a cipher.
+	v3   *WhatsminerAPIV3
+	salt string
 }
 
 // NewWhatsminerAccessToken creates a new instance of WhatsminerAccessToken.
 func NewWhatsminerAccessToken(ipAddress string, port int, adminPassword string) (*WhatsminerAccessToken, error) {
+	return NewWhatsminerAccessTokenContext(context.Background(), ipAddress, port, adminPassword)
+}
+
+// NewWhatsminerAccessTokenContext is like NewWhatsminerAccessToken but uses ctx for the initial token handshake.
+func NewWhatsminerAccessTokenContext(ctx context.Context, ipAddress string, port int, adminPassword string) (*WhatsminerAccessToken, error) {
+	return defaultAPI.NewAccessToken(ctx, ipAddress, port, adminPassword)
+}
+
+// NewAccessToken creates a token whose handshakes and refreshes go through w, so they share its
+// dialer and timeouts.
+func (w *WhatsminerAPI) NewAccessToken(ctx context.Context, ipAddress string, port int, adminPassword string) (*WhatsminerAccessToken, error) {
 
 	token := &WhatsminerAccessToken{
 		Created:   time.Now(),
 		IPAddress: ipAddress,
 		Port:      port,
 		stop:      make(chan bool),
+		api:       w,
 	}
 
 	if adminPassword != "" {
-		if err := token.EnableWriteAccess(adminPassword); err != nil {
+		if err := token.EnableWriteAccessContext(ctx, adminPassword); err != nil {
 			return nil, fmt.Errorf("error while trying to enable write access: %w", err)
 		}
 	}
@@ -67,48 +86,57 @@ func (t *WhatsminerAccessToken) Close() {
 	}
 }
 
-func (t *WhatsminerAccessToken) getTokenInfo() (map[string]any, error) {
-	conn, err := net.DialTimeout("tcp", net.JoinHostPort(t.IPAddress, fmt.Sprintf("%d", t.Port)), 5*time.Second)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to miner: %w", err)
-	}
-	defer conn.Close()
-
-	if _, err := conn.Write([]byte(`{"cmd": "get_token"}`)); err != nil {
-		return nil, fmt.Errorf("failed to send get_token command: %w", err)
+// transport returns the API used for the token's own requests.
+func (t *WhatsminerAccessToken) transport() *WhatsminerAPI {
+	if t.api == nil {
+		return defaultAPI
 	}
+	return t.api
+}
 
-	response, err := io.ReadAll(conn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read token response: %w", err)
-	}
+func (t *WhatsminerAccessToken) getTokenInfo(ctx context.Context) (map[string]any, error) {
+	api := t.transport()
+	return withRetry(ctx, api, "get_token", false, func() (map[string]any, error) {
+		response, err := api.roundTrip(ctx, t.IPAddress, t.Port, []byte(`{"cmd": "get_token"}`))
+		if err != nil {
+			return nil, fmt.Errorf("get_token failed: %w", err)
+		}
 
-	var tokenInfo map[string]any
-	if err := json.Unmarshal(response, &tokenInfo); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal token response: %w", err)
-	}
+		var tokenInfo map[string]any
+		if err := json.Unmarshal(response, &tokenInfo); err != nil {
+			return nil, fmt.Errorf("%w: failed to unmarshal token response: %w", ErrInvalidResponse, err)
+		}
 
-	if msg, ok := tokenInfo["Msg"].(string); ok && msg == "over max connect" {
-		return nil, errors.New(msg)
-	}
+		if err := statusError("get_token", tokenInfo); err != nil {
+			return nil, err
+		}
 
-	return tokenInfo, nil
+		return tokenInfo, nil
+	})
 }
 
 func (t *WhatsminerAccessToken) generateCipherAndSign(tokenInfo map[string]any, adminPassword string) error {
 	msg, ok := tokenInfo["Msg"].(map[string]any)
 	if !ok {
-		return errors.New("invalid token format in 'Msg' field")
+		return fmt.Errorf("%w: invalid token format in 'Msg' field", ErrInvalidResponse)
 	}
 
 	salt, _ := msg["salt"].(string)
 	newsalt, _ := msg["newsalt"].(string)
-	tokenTime, _ := msg["time"].(float64)
+
+	// Firmware reports the token time as a string; tolerate a bare number as well.
+	var tokenTime string
+	switch v := msg["time"].(type) {
+	case string:
+		tokenTime = v
+	case float64:
+		tokenTime = strconv.FormatFloat(v, 'f', -1, 64)
+	}
 
 	fullSalt := fmt.Sprintf("$1$%s$", salt)
 	r := regexp.MustCompile(`\s*\$(\d+)\$([\w\./]*)\$`)
 	if !r.MatchString(fullSalt) {
-		return errors.New("salt format is not correct")
+		return fmt.Errorf("%w: salt format is not correct", ErrInvalidResponse)
 	}
 
 	m := md5_crypt.New()
@@ -126,7 +154,7 @@ func (t *WhatsminerAccessToken) generateCipherAndSign(tokenInfo map[string]any,
 	}
 
 	fullNewSalt := fmt.Sprintf("$1$%s$", newsalt)
-	signHash, err := m.Generate([]byte(key+fmt.Sprint(tokenTime)), []byte(fullNewSalt))
+	signHash, err := m.Generate([]byte(key+tokenTime), []byte(fullNewSalt))
 	if err != nil {
 		return fmt.Errorf("error while generating sign hash: %w", err)
 	}
@@ -136,9 +164,18 @@ func (t *WhatsminerAccessToken) generateCipherAndSign(tokenInfo map[string]any,
 }
 
 // initializeWriteAccess initializes write access for the token. The caller must hold the mutex.
-func (t *WhatsminerAccessToken) initializeWriteAccess(adminPassword string) error {
+func (t *WhatsminerAccessToken) initializeWriteAccess(ctx context.Context, adminPassword string) error {
+	if t.v3 != nil {
+		salt, err := t.v3.fetchSalt(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed to get salt: %w", err)
+		}
+		t.salt = salt
+		t.Created = time.Now()
+		return nil
+	}
 
-	tokenInfo, err := t.getTokenInfo()
+	tokenInfo, err := t.getTokenInfo(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get token info: %w", err)
 	}
@@ -153,6 +190,17 @@ func (t *WhatsminerAccessToken) initializeWriteAccess(adminPassword string) erro
 
 // monitorToken runs in the background to keep the token fresh.
 func (t *WhatsminerAccessToken) monitorToken() {
+	// Cancel any in-flight refresh as soon as the token is closed.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-t.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	for {
 		t.mu.Lock()
 		// Calculate duration until next refresh
@@ -171,7 +219,7 @@ func (t *WhatsminerAccessToken) monitorToken() {
 			t.mu.Lock()
 			// Check if a refresh is still needed, as another thread might have done it.
 			if time.Since(t.Created).Minutes() >= 25 {
-				if err := t.initializeWriteAccess(t.AdminPassword); err != nil {
+				if err := t.initializeWriteAccess(ctx, t.AdminPassword); err != nil {
 					if t.OnRefreshError != nil {
 						t.OnRefreshError(err)
 					} else {
@@ -189,28 +237,60 @@ func (t *WhatsminerAccessToken) monitorToken() {
 
 // EnableWriteAccess enables write access for the token and starts the background refresh.
 func (t *WhatsminerAccessToken) EnableWriteAccess(adminPassword string) error {
+	return t.EnableWriteAccessContext(context.Background(), adminPassword)
+}
+
+// EnableWriteAccessContext is like EnableWriteAccess but uses ctx for the token handshake.
+func (t *WhatsminerAccessToken) EnableWriteAccessContext(ctx context.Context, adminPassword string) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.AdminPassword = adminPassword
-	if err := t.initializeWriteAccess(adminPassword); err != nil {
+	if err := t.initializeWriteAccess(ctx, adminPassword); err != nil {
 		return fmt.Errorf("error enabling write access: %w", err)
 	}
 	go t.monitorToken()
 	return nil
 }
 
+// renew forces a new token handshake, e.g. after the miner rejected the current sign.
+func (t *WhatsminerAccessToken) renew(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.initializeWriteAccess(ctx, t.AdminPassword); err != nil {
+		return fmt.Errorf("error trying to renew write access: %w", err)
+	}
+	return nil
+}
+
+// UpdatePassword re-derives the token from newPassword after the miner's admin password has been
+// changed, so later writes are signed with it. The old password is kept if the handshake fails.
+func (t *WhatsminerAccessToken) UpdatePassword(ctx context.Context, newPassword string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.initializeWriteAccess(ctx, newPassword); err != nil {
+		return fmt.Errorf("error trying to renew write access with the new password: %w", err)
+	}
+	t.AdminPassword = newPassword
+	return nil
+}
+
 // HasWriteAccess checks write access and refreshes the token if necessary.
 func (t *WhatsminerAccessToken) HasWriteAccess() error {
+	return t.HasWriteAccessContext(context.Background())
+}
+
+// HasWriteAccessContext is like HasWriteAccess but uses ctx if the token has to be renewed.
+func (t *WhatsminerAccessToken) HasWriteAccessContext(ctx context.Context) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	if t.AdminPassword == "" {
-		return errors.New("admin password is not set")
+		return fmt.Errorf("%w: admin password is not set", ErrWriteAccessDisabled)
 	}
 
 	if time.Since(t.Created).Minutes() > 30 {
 		// Writeable token has expired; reinitialize
-		if err := t.initializeWriteAccess(t.AdminPassword); err != nil {
+		if err := t.initializeWriteAccess(ctx, t.AdminPassword); err != nil {
 			return fmt.Errorf("error trying to renew write access: %w", err)
 		}
 	}
@@ -218,42 +298,126 @@ func (t *WhatsminerAccessToken) HasWriteAccess() error {
 	return nil
 }
 
-// WhatsminerAPI represents a stateless class with only class methods for read/write API calls.
-type WhatsminerAPI struct{}
+// WhatsminerAPI sends read/write API calls. It holds only connection settings, so a single
+// instance can be shared by any number of tokens. The zero value uses the defaults.
+type WhatsminerAPI struct {
+	dialer          Dialer
+	dialTimeout     time.Duration
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	maxResponseSize int64
+	localAddr       net.Addr
+	retry           RetryPolicy
+	limiter         *HostLimiter
+	v3Port          int
+	idempotent      func(cmd string) bool
+}
+
+// defaultAPI is used by tokens that were not created through a configured WhatsminerAPI.
+var defaultAPI = &WhatsminerAPI{}
+
+// NewWhatsminerAPI creates a WhatsminerAPI configured with opts.
+func NewWhatsminerAPI(opts ...Option) *WhatsminerAPI {
+	w := &WhatsminerAPI{}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
 
 // GetReadOnlyInfo sends a READ-ONLY API command.
 func (w *WhatsminerAPI) GetReadOnlyInfo(accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) (map[string]any, error) {
-	jsonCmd := map[string]any{"cmd": cmd}
-	maps.Copy(jsonCmd, additionalParams)
+	return w.GetReadOnlyInfoContext(context.Background(), accessToken, cmd, additionalParams)
+}
 
-	conn, err := net.DialTimeout("tcp", net.JoinHostPort(accessToken.IPAddress, fmt.Sprintf("%d", accessToken.Port)), 5*time.Second)
+// GetReadOnlyInfoContext sends a READ-ONLY API command. The dial, write and read are all bound to ctx.
+// Failed attempts are retried according to the retry policy.
+func (w *WhatsminerAPI) GetReadOnlyInfoContext(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) (map[string]any, error) {
+	resp, err := w.GetReadOnlyRaw(ctx, accessToken, cmd, additionalParams)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to miner: %w", err)
+		return nil, err
 	}
-	defer conn.Close()
+	return unmarshalMap(resp)
+}
 
-	if err := json.NewEncoder(conn).Encode(jsonCmd); err != nil {
+// GetReadOnlyRaw is like GetReadOnlyInfoContext but returns the JSON response as sent by the miner,
+// so callers can decode it into their own types. A token created by WhatsminerAPIV3 is handed on
+// to it, so the command is sent over API 3.x.
+func (w *WhatsminerAPI) GetReadOnlyRaw(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) ([]byte, error) {
+	if accessToken.v3 != nil {
+		return accessToken.v3.GetReadOnlyRaw(ctx, accessToken, cmd, additionalParams)
+	}
+	return withRetry(ctx, w, cmd, false, func() ([]byte, error) {
+		return w.getReadOnlyRaw(ctx, accessToken, cmd, additionalParams)
+	})
+}
+
+func (w *WhatsminerAPI) getReadOnlyRaw(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) ([]byte, error) {
+	jsonCmd := map[string]any{"cmd": cmd}
+	maps.Copy(jsonCmd, additionalParams)
+
+	apiCmd, err := json.Marshal(jsonCmd)
+	if err != nil {
 		return nil, fmt.Errorf("failed to encode command: %w", err)
 	}
 
-	resp, err := io.ReadAll(conn)
+	resp, err := w.roundTrip(ctx, accessToken.IPAddress, accessToken.Port, apiCmd)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	sanitizedResp := sanitizeJSONResponse(string(resp))
+	resp = repairJSON(resp)
 
 	var result map[string]any
-	if err := json.Unmarshal([]byte(sanitizedResp), &result); err != nil {
-		return nil, fmt.Errorf("error while trying to unmarshal resp: %w", err)
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
 	}
 
-	return result, nil
+	if err := statusError(cmd, result); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
 }
 
 // ExecCommand sends a WRITEABLE API command.
 func (w *WhatsminerAPI) ExecCommand(accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) (map[string]any, error) {
-	if err := accessToken.HasWriteAccess(); err != nil {
+	return w.ExecCommandContext(context.Background(), accessToken, cmd, additionalParams)
+}
+
+// ExecCommandContext sends a WRITEABLE API command. The token renewal, dial, write and read are all bound to ctx.
+// Failed attempts are retried according to the retry policy; a rejected token is renewed before the
+// next attempt.
+func (w *WhatsminerAPI) ExecCommandContext(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) (map[string]any, error) {
+	resp, err := w.ExecCommandRaw(ctx, accessToken, cmd, additionalParams)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalMap(resp)
+}
+
+// ExecCommandRaw is like ExecCommandContext but returns the decrypted JSON response, so callers can
+// decode it into their own types. As with GetReadOnlyRaw, a token created by WhatsminerAPIV3 is
+// handed on to it.
+func (w *WhatsminerAPI) ExecCommandRaw(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) ([]byte, error) {
+	if accessToken.v3 != nil {
+		return accessToken.v3.ExecCommandRaw(ctx, accessToken, cmd, additionalParams)
+	}
+	var lastErr error
+	return withRetry(ctx, w, cmd, true, func() ([]byte, error) {
+		if errors.Is(lastErr, ErrTokenExpired) {
+			if err := accessToken.renew(ctx); err != nil {
+				return nil, err
+			}
+		}
+		var resp []byte
+		resp, lastErr = w.execCommandRaw(ctx, accessToken, cmd, additionalParams)
+		return resp, lastErr
+	})
+}
+
+func (w *WhatsminerAPI) execCommandRaw(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) ([]byte, error) {
+	if err := accessToken.HasWriteAccessContext(ctx); err != nil {
 		return nil, fmt.Errorf("token has no write access: %w", err)
 	}
 
@@ -261,9 +425,25 @@ func (w *WhatsminerAPI) ExecCommand(accessToken *WhatsminerAccessToken, cmd stri
 	defer accessToken.mu.Unlock()
 
 	if accessToken.Cipher == nil {
-		return nil, errors.New("cipher not initialized - write access may have failed")
+		return nil, fmt.Errorf("%w: cipher not initialized", ErrWriteAccessDisabled)
+	}
+
+	encCmd, err := encryptCommand(accessToken, cmd, additionalParams)
+	if err != nil {
+		return nil, err
 	}
 
+	resp, err := w.roundTrip(ctx, accessToken.IPAddress, accessToken.Port, encCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptResponse(accessToken, cmd, resp)
+}
+
+// encryptCommand builds the encrypted request for a write command. The caller must hold the
+// token mutex.
+func encryptCommand(accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) ([]byte, error) {
 	jsonCmd := map[string]any{"cmd": cmd, "token": accessToken.Sign}
 	maps.Copy(jsonCmd, additionalParams)
 
@@ -285,58 +465,209 @@ func (w *WhatsminerAPI) ExecCommand(accessToken *WhatsminerAccessToken, cmd stri
 		"data": encStr,
 	}
 
-	conn, err := net.Dial("tcp", net.JoinHostPort(accessToken.IPAddress, fmt.Sprintf("%d", accessToken.Port)))
+	encCmd, err := json.Marshal(dataEnc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to miner: %w", err)
-	}
-	defer conn.Close()
-
-	if err := json.NewEncoder(conn).Encode(dataEnc); err != nil {
 		return nil, fmt.Errorf("error encoding data: %w", err)
 	}
+	return encCmd, nil
+}
 
-	resp, err := io.ReadAll(conn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	sanitizedResp := sanitizeJSONResponse(string(resp))
+// decryptResponse checks the reply to a write command and returns its decrypted JSON. The caller
+// must hold the token mutex.
+func decryptResponse(accessToken *WhatsminerAccessToken, cmd string, resp []byte) ([]byte, error) {
+	resp = repairJSON(resp)
 
 	var result map[string]any
-	if err := json.Unmarshal([]byte(sanitizedResp), &result); err != nil {
-		return nil, fmt.Errorf("error while trying to unmarshal resp: %w", err)
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
 	}
 
-	if status, ok := result["STATUS"].(string); ok && status == "E" {
-		if msg, ok := result["Msg"].(string); ok {
-			return nil, fmt.Errorf("miner API error: %s", msg)
-		}
-		return nil, errors.New("unknown miner API error")
+	if err := statusError(cmd, result); err != nil {
+		err.Encrypted = true
+		return nil, err
 	}
 
 	encResult, ok := result["enc"].(string)
 	if !ok {
-		return nil, errors.New("encrypted response not found")
+		return nil, fmt.Errorf("%w: encrypted response not found", ErrInvalidResponse)
 	}
 
 	respCiphertext, err := base64.StdEncoding.DecodeString(encResult)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode encrypted response: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
 	}
 
 	respPlaintext, err := decrypt(string(respCiphertext), accessToken.Cipher)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt response: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
 	}
 
-	respFinal := strings.Split(respPlaintext, "\x00")[0]
-	if err := json.Unmarshal([]byte(respFinal), &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal decrypted response: %w", err)
+	respFinal := repairJSON([]byte(strings.Split(respPlaintext, "\x00")[0]))
+	if !json.Valid(respFinal) {
+		return nil, fmt.Errorf("%w: decrypted response is not JSON", ErrDecrypt)
 	}
 
+	return respFinal, nil
+}
+
+// unmarshalMap decodes a JSON object response.
+func unmarshalMap(resp []byte) (map[string]any, error) {
+	var result map[string]any
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
 	return result, nil
 }
 
+// roundTrip sends a single request to the miner and reads the response until the miner closes
+// the connection.
+func (w *WhatsminerAPI) roundTrip(ctx context.Context, ipAddress string, port int, request []byte) ([]byte, error) {
+	return w.exchange(ctx, ipAddress, port, append(request, '\n'), w.readResponse)
+}
+
+// exchange writes frame to a new connection to the miner and returns what read makes of the reply.
+func (w *WhatsminerAPI) exchange(ctx context.Context, ipAddress string, port int, frame []byte, read func(io.Reader) ([]byte, error)) ([]byte, error) {
+	var resp []byte
+	err := w.session(ctx, ipAddress, port, func(conn net.Conn, addr string) error {
+		setPhaseDeadline(ctx, conn.SetWriteDeadline, w.writeTimeout)
+		if _, err := conn.Write(frame); err != nil {
+			return &ConnError{Op: "write", Addr: addr, Err: ctxErr(ctx, err)}
+		}
+
+		setPhaseDeadline(ctx, conn.SetReadDeadline, w.readTimeout)
+		var err error
+		resp, err = read(conn)
+		if err != nil {
+			return &ConnError{Op: "read", Addr: addr, Err: ctxErr(ctx, err)}
+		}
+		return nil
+	})
+	return resp, err
+}
+
+// session opens a connection to the miner within its host limit and hands it to fn. The
+// connection is bound to ctx and closed when fn returns.
+func (w *WhatsminerAPI) session(ctx context.Context, ipAddress string, port int, fn func(conn net.Conn, addr string) error) error {
+	addr := net.JoinHostPort(ipAddress, strconv.Itoa(port))
+
+	release, err := w.hostLimiter().Acquire(ctx, addr)
+	if err != nil {
+		return &ConnError{Op: "dial", Addr: addr, Err: err}
+	}
+	defer release()
+
+	conn, err := w.dial(ctx, addr)
+	if err != nil {
+		return &ConnError{Op: "dial", Addr: addr, Err: err}
+	}
+	defer conn.Close()
+	defer watchContext(ctx, conn)()
+
+	return fn(conn, addr)
+}
+
+// hostLimiter returns the limiter bounding concurrent connections per miner.
+func (w *WhatsminerAPI) hostLimiter() *HostLimiter {
+	if w.limiter == nil {
+		return DefaultHostLimiter
+	}
+	return w.limiter
+}
+
+// dial opens a connection to the miner, giving up when ctx is done or the dial timeout elapses.
+func (w *WhatsminerAPI) dial(ctx context.Context, addr string) (net.Conn, error) {
+	timeout := w.dialTimeout
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+
+	dialer := w.dialer
+	if dialer == nil {
+		dialer = &net.Dialer{LocalAddr: w.localAddr}
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := dialer.DialContext(dialCtx, "tcp", addr)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	return conn, nil
+}
+
+// responseLimit returns the configured maximum response size; it is negative if there is none.
+func (w *WhatsminerAPI) responseLimit() int64 {
+	if w.maxResponseSize == 0 {
+		return DefaultMaxResponseSize
+	}
+	return w.maxResponseSize
+}
+
+// readResponse reads until EOF, enforcing the configured maximum response size.
+func (w *WhatsminerAPI) readResponse(r io.Reader) ([]byte, error) {
+	limit := w.responseLimit()
+	if limit < 0 {
+		return io.ReadAll(r)
+	}
+
+	resp, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(resp)) > limit {
+		return nil, ErrResponseTooLarge
+	}
+	return resp, nil
+}
+
+// watchContext applies the deadline of ctx to conn and unblocks any pending I/O once ctx is done.
+// The returned function must be called to release the watcher.
+func watchContext(ctx context.Context, conn net.Conn) func() {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+	return func() { stop() }
+}
+
+// phaseDeadline returns the earlier of now+timeout and the deadline of ctx. A zero timeout
+// means only the context deadline applies.
+func phaseDeadline(ctx context.Context, timeout time.Duration) time.Time {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	if ctx.Err() != nil {
+		// Keep the already-expired deadline set by watchContext.
+		deadline = time.Unix(1, 0)
+	}
+	return deadline
+}
+
+// setPhaseDeadline sets a read or write deadline of a connection watched by watchContext with
+// set. If ctx ends while the deadline is being set, the expired deadline set by watchContext may
+// be overwritten, so ctx is checked again once it is set.
+func setPhaseDeadline(ctx context.Context, set func(time.Time) error, timeout time.Duration) {
+	set(phaseDeadline(ctx, timeout))
+	if ctx.Err() != nil {
+		set(time.Unix(1, 0))
+	}
+}
+
+// ctxErr prefers the context error over err so callers can match context.Canceled and context.DeadlineExceeded.
+func ctxErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
 func decrypt(cipherstring string, block cipher.Block) (string, error) {
 	ciphertext := []byte(cipherstring)
 
@@ -355,13 +686,6 @@ func decrypt(cipherstring string, block cipher.Block) (string, error) {
 	return string(ciphertext), nil
 }
 
-// sanitizeJSONResponse replaces non-standard JSON values from the miner response.
-func sanitizeJSONResponse(resp string) string {
-	resp = strings.ReplaceAll(resp, "inf", "999")
-	resp = strings.ReplaceAll(resp, "nan", "0")
-	return resp
-}
-
 // addTo16 pads a byte slice to a multiple of 16 bytes with null bytes.
 func addTo16(b []byte) []byte {
 	padSize := 16 - (len(b) % 16)
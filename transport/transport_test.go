@@ -0,0 +1,39 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// TestCancelDuringRead cancels a request while a slow miner is still sending its response and
+// checks that it ends promptly with context.Canceled rather than at the read timeout.
+func TestCancelDuringRead(t *testing.T) {
+	sim := startSim(t)
+	sim.InjectFault(wmapisim.Fault{Kind: wmapisim.FaultSlowLoris, Cmd: "summary", Delay: 20 * time.Millisecond})
+	api := transport.NewWhatsminerAPI(transport.WithReadTimeout(time.Minute))
+	token := &transport.WhatsminerAccessToken{IPAddress: sim.Host(), Port: sim.Port()}
+
+	for range 5 {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		start := time.Now()
+		_, err := api.GetReadOnlyRaw(ctx, token, "summary", nil)
+		cancel()
+
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("GetReadOnlyRaw = %v, want context.Canceled", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Fatalf("GetReadOnlyRaw returned %v after the cancellation, want it to end promptly", elapsed)
+		}
+		var connErr *transport.ConnError
+		if !errors.As(err, &connErr) || connErr.Op != "read" {
+			t.Errorf("GetReadOnlyRaw = %v, want a read ConnError", err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"time"
+)
+
+// Uploader is implemented by protocols that can stream a payload after a write command on the
+// same connection, as update_firmware requires. WhatsminerAPIV3 does not implement it.
+type Uploader interface {
+	ExecCommandUpload(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any, body io.Reader, size int64, progress func(sent int64)) ([]byte, error)
+}
+
+var _ Uploader = (*WhatsminerAPI)(nil)
+
+// uploadChunkSize is how much of an upload is written between progress reports.
+const uploadChunkSize = 64 << 10
+
+// ExecCommandUpload sends the write command cmd and, once the miner has answered "ready" on the
+// same connection, streams size bytes from body prefixed with their length as a 4-byte
+// little-endian integer. progress, if not nil, is called with the number of bytes sent after every
+// chunk. The write timeout applies to each chunk and the read timeout to each reply.
+//
+// It returns the decrypted final reply, or nil if the miner closed the connection without one, as
+// some firmware does when it reboots straight away. The command is never retried because body
+// cannot be rewound. Afterwards the token is renewed on its next use.
+func (w *WhatsminerAPI) ExecCommandUpload(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any, body io.Reader, size int64, progress func(sent int64)) ([]byte, error) {
+	if accessToken.v3 != nil {
+		return nil, fmt.Errorf("%w: %s over API 3.x", ErrUnsupported, cmd)
+	}
+	if size <= 0 || size > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid upload size %d", size)
+	}
+	encCmd, err := sealCommand(ctx, accessToken, cmd, additionalParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []byte
+	err = w.session(ctx, accessToken.IPAddress, accessToken.Port, func(conn net.Conn, addr string) error {
+		if err := w.writeCommand(ctx, conn, addr, encCmd); err != nil {
+			return err
+		}
+
+		ack, rest, err := w.readReply(ctx, conn, addr)
+		if err != nil {
+			return err
+		}
+		if err := checkReady(accessToken, cmd, ack); err != nil {
+			return err
+		}
+
+		if err := w.writeUpload(ctx, conn, addr, body, size, progress); err != nil {
+			return err
+		}
+
+		setPhaseDeadline(ctx, conn.SetReadDeadline, w.readTimeout)
+		resp, err = w.readResponse(rest)
+		if err != nil {
+			return &ConnError{Op: "read", Addr: addr, Err: ctxErr(ctx, err)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken.mu.Lock()
+	defer accessToken.mu.Unlock()
+	// The miner forgets its tokens when it reboots into the upload; force a new handshake on
+	// the next write.
+	accessToken.Created = time.Time{}
+	if len(resp) == 0 {
+		return nil, nil
+	}
+	return decryptResponse(accessToken, cmd, resp)
+}
+
+// sealCommand renews the token if needed and encrypts the write command cmd.
+func sealCommand(ctx context.Context, accessToken *WhatsminerAccessToken, cmd string, additionalParams map[string]any) ([]byte, error) {
+	if err := accessToken.HasWriteAccessContext(ctx); err != nil {
+		return nil, fmt.Errorf("token has no write access: %w", err)
+	}
+
+	accessToken.mu.Lock()
+	defer accessToken.mu.Unlock()
+	if accessToken.Cipher == nil {
+		return nil, fmt.Errorf("%w: cipher not initialized", ErrWriteAccessDisabled)
+	}
+	return encryptCommand(accessToken, cmd, additionalParams)
+}
+
+// writeCommand sends a newline-terminated command on a streaming connection.
+func (w *WhatsminerAPI) writeCommand(ctx context.Context, conn net.Conn, addr string, encCmd []byte) error {
+	setPhaseDeadline(ctx, conn.SetWriteDeadline, w.writeTimeout)
+	if _, err := conn.Write(append(encCmd, '\n')); err != nil {
+		return &ConnError{Op: "write", Addr: addr, Err: ctxErr(ctx, err)}
+	}
+	return nil
+}
+
+// readReply reads the single JSON reply the miner sends before a raw transfer. It also returns a
+// reader positioned at the first byte after the reply.
+func (w *WhatsminerAPI) readReply(ctx context.Context, conn net.Conn, addr string) (json.RawMessage, io.Reader, error) {
+	setPhaseDeadline(ctx, conn.SetReadDeadline, w.readTimeout)
+	var src io.Reader = conn
+	limited := &io.LimitedReader{R: conn, N: w.responseLimit()}
+	if limited.N > 0 {
+		src = limited
+	}
+	dec := json.NewDecoder(src)
+	var reply json.RawMessage
+	if err := dec.Decode(&reply); err != nil {
+		if limited.N == 0 {
+			err = ErrResponseTooLarge
+		}
+		return nil, nil, &ConnError{Op: "read", Addr: addr, Err: ctxErr(ctx, err)}
+	}
+	return reply, io.MultiReader(dec.Buffered(), conn), nil
+}
+
+// checkReady verifies the miner accepted the command and is waiting for the payload.
+func checkReady(accessToken *WhatsminerAccessToken, cmd string, ack []byte) error {
+	accessToken.mu.Lock()
+	plain, err := decryptResponse(accessToken, cmd, ack)
+	accessToken.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var reply struct {
+		Msg any `json:"Msg"`
+	}
+	if err := json.Unmarshal(plain, &reply); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	if reply.Msg != "ready" {
+		return fmt.Errorf("%w: miner is not ready for the upload: %v", ErrInvalidResponse, reply.Msg)
+	}
+	return nil
+}
+
+// writeUpload streams the length-prefixed payload.
+func (w *WhatsminerAPI) writeUpload(ctx context.Context, conn net.Conn, addr string, body io.Reader, size int64, progress func(sent int64)) error {
+	setPhaseDeadline(ctx, conn.SetWriteDeadline, w.writeTimeout)
+	if _, err := conn.Write(binary.LittleEndian.AppendUint32(nil, uint32(size))); err != nil {
+		return &ConnError{Op: "write", Addr: addr, Err: ctxErr(ctx, err)}
+	}
+
+	buf := make([]byte, uploadChunkSize)
+	for sent := int64(0); sent < size; {
+		n, err := io.ReadFull(body, buf[:min(int64(len(buf)), size-sent)])
+		if err != nil {
+			return fmt.Errorf("failed to read upload after %d of %d bytes: %w", sent, size, err)
+		}
+
+		setPhaseDeadline(ctx, conn.SetWriteDeadline, w.writeTimeout)
+		if _, err := conn.Write(buf[:n]); err != nil {
+			return &ConnError{Op: "write", Addr: addr, Err: ctxErr(ctx, err)}
+		}
+		sent += int64(n)
+		if progress != nil {
+			progress(sent)
+		}
+	}
+	return nil
+}
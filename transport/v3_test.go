@@ -0,0 +1,35 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestReadFrameLimit(t *testing.T) {
+	frame := func(size uint32, payload string) *bytes.Reader {
+		b := binary.LittleEndian.AppendUint32(nil, size)
+		return bytes.NewReader(append(b, payload...))
+	}
+	tests := []struct {
+		name    string
+		limit   int64
+		size    uint32
+		wantErr error
+	}{
+		{"within the default limit", 0, 2, nil},
+		{"above the configured limit", 1, 2, ErrResponseTooLarge},
+		{"limit disabled", -1, 2, nil},
+		{"limit disabled and a corrupt prefix", -1, math.MaxUint32, ErrResponseTooLarge},
+		{"limit above the frame cap", 1 << 40, maxV3FrameSize + 1, ErrResponseTooLarge},
+	}
+	for _, tt := range tests {
+		w := NewWhatsminerAPI(WithMaxResponseSize(tt.limit))
+		resp, err := w.readFrame(frame(tt.size, "{}"))
+		if !errors.Is(err, tt.wantErr) || tt.wantErr == nil && string(resp) != "{}" {
+			t.Errorf("%s: readFrame = %q, %v, want %v", tt.name, resp, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// v3Command describes how a 2.x command is expressed in API 3.x.
+type v3Command struct {
+	method string
+	// param builds the 3.x parameter from the 2.x parameters.
+	param func(params map[string]any) (any, error)
+	// reply converts a successful 3.x reply into the 2.x response shape.
+	reply func(r *v3Reply) (any, error)
+}
+
+// v3Translate returns the 3.x form of cmd. Names containing a dot are native 3.x commands. The
+// write flag keeps a read-only call from ever reaching a set.* method.
+func v3Translate(cmd string, write bool) (v3Command, bool) {
+	if strings.Contains(cmd, ".") {
+		return v3Command{method: cmd, param: paramValue("param"), reply: nativeReply}, true
+	}
+	commands := v3Reads
+	if write {
+		commands = v3Writes
+	}
+	c, ok := commands[cmd]
+	return c, ok
+}
+
+var v3Reads = map[string]v3Command{
+	"summary":        {"get.miner.status", fixedParam("summary"), summaryReply},
+	"pools":          {"get.miner.status", fixedParam("pools"), poolsReply},
+	"edevs":          {"get.miner.status", fixedParam("edevs"), edevsReply},
+	"devdetails":     {"get.miner.status", fixedParam("edevs"), devdetailsReply},
+	"get_psu":        {"get.device.info", fixedParam(nil), deviceReply(psuReply)},
+	"get_version":    {"get.device.info", fixedParam(nil), deviceReply(versionReply)},
+	"status":         {"get.device.info", fixedParam(nil), deviceReply(statusReply)},
+	"get_miner_info": {"get.device.info", fixedParam(nil), deviceReply(minerInfoReply)},
+	"get_error_code": {"get.device.info", fixedParam(nil), deviceReply(errorCodeReply)},
+}
+
+// v3Writes lacks the web pools and btminer init switches, which 3.x firmware does not offer.
+var v3Writes = map[string]v3Command{
+	"update_pools":              {"set.miner.pools", poolsParam, commandReply},
+	"restart_btminer":           {"set.miner.service", fixedParam("restart"), commandReply},
+	"power_off":                 {"set.miner.service", fixedParam("stop"), commandReply},
+	"power_on":                  {"set.miner.service", fixedParam("start"), commandReply},
+	"set_led":                   {"set.system.led", ledParam, commandReply},
+	"set_low_power":             {"set.miner.power_mode", fixedParam("low"), commandReply},
+	"set_normal_power":          {"set.miner.power_mode", fixedParam("normal"), commandReply},
+	"set_high_power":            {"set.miner.power_mode", fixedParam("high"), commandReply},
+	"reboot":                    {"set.system.reboot", fixedParam(nil), commandReply},
+	"factory_reset":             {"set.system.factory_reset", fixedParam(nil), commandReply},
+	"update_pwd":                {"set.user.change_passwd", passwordParam, commandReply},
+	"net_config":                {"set.system.net_config", netConfigParam, commandReply},
+	"set_target_freq":           {"set.miner.target_freq", paramValue("percent"), commandReply},
+	"enable_btminer_fast_boot":  {"set.miner.fastboot", fixedParam("enable"), commandReply},
+	"disable_btminer_fast_boot": {"set.miner.fastboot", fixedParam("disable"), commandReply},
+	"set_hostname":              {"set.system.hostname", paramValue("hostname"), commandReply},
+	"set_power_pct":             {"set.miner.power_percent", paramValue("percent"), commandReply},
+	"set_power_pct_v2":          {"set.miner.power_percent", paramValue("percent"), commandReply},
+	"set_temp_offset":           {"set.miner.temp_offset", paramValue("temp_offset"), commandReply},
+	"adjust_power_limit":        {"set.miner.power_limit", paramValue("power_limit"), commandReply},
+	"adjust_upfreq_speed":       {"set.miner.upfreq_speed", paramValue("upfreq_speed"), commandReply},
+	"set_poweroff_cool":         {"set.miner.poweroff_cool", paramValue("poweroff_cool"), commandReply},
+	"set_fan_zero_speed":        {"set.fan.zero_speed", paramValue("fan_zero_speed"), commandReply},
+}
+
+func fixedParam(v any) func(map[string]any) (any, error) {
+	return func(map[string]any) (any, error) { return v, nil }
+}
+
+func paramValue(key string) func(map[string]any) (any, error) {
+	return func(params map[string]any) (any, error) { return params[key], nil }
+}
+
+func poolsParam(params map[string]any) (any, error) {
+	var list []map[string]any
+	for i := 1; i <= 3; i++ {
+		url, _ := params[fmt.Sprintf("pool%d", i)].(string)
+		if url == "" {
+			continue
+		}
+		list = append(list, map[string]any{
+			"pool":   url,
+			"worker": params[fmt.Sprintf("worker%d", i)],
+			"passwd": params[fmt.Sprintf("passwd%d", i)],
+		})
+	}
+	return list, nil
+}
+
+func ledParam(params map[string]any) (any, error) {
+	if mode, ok := params["param"].(string); ok {
+		return mode, nil
+	}
+	return maps.Clone(params), nil
+}
+
+func passwordParam(params map[string]any) (any, error) {
+	return map[string]any{"account": v3Account, "old": params["old"], "new": params["new"]}, nil
+}
+
+func netConfigParam(params map[string]any) (any, error) {
+	if params["param"] == "dhcp" {
+		return map[string]any{"proto": "dhcp"}, nil
+	}
+	conf := maps.Clone(params)
+	conf["proto"] = "static"
+	return conf, nil
+}
+
+// v3Field maps a 3.x field onto a 2.x one. Hash rates are reported in TH/s by 3.x firmware, so
+// scale converts them to the MH/s or GH/s of the 2.x field. str formats the value as a string,
+// as 2.x firmware does for some fields.
+type v3Field struct {
+	from, to string
+	scale    float64
+	str      bool
+}
+
+const (
+	thToMH = 1e6
+	thToGH = 1e3
+)
+
+var summaryFields = []v3Field{
+	{from: "elapsed", to: "Elapsed"},
+	{from: "bootup-time", to: "Uptime"},
+	{from: "hash-average", to: "MHS av", scale: thToMH},
+	{from: "hash-realtime", to: "MHS 5s", scale: thToMH},
+	{from: "hash-realtime", to: "HS RT", scale: thToMH},
+	{from: "hash-1min", to: "MHS 1m", scale: thToMH},
+	{from: "hash-5min", to: "MHS 5m", scale: thToMH},
+	{from: "hash-15min", to: "MHS 15m", scale: thToMH},
+	{from: "target-hash", to: "Target MHS", scale: thToMH},
+	{from: "factory-hash", to: "Factory GHS", scale: thToGH},
+	{from: "accepted", to: "Accepted"},
+	{from: "rejected", to: "Rejected"},
+	{from: "temperature", to: "Temperature"},
+	{from: "freq-avg", to: "freq_avg"},
+	{from: "target-freq", to: "Target Freq"},
+	{from: "fan-speed-in", to: "Fan Speed In"},
+	{from: "fan-speed-out", to: "Fan Speed Out"},
+	{from: "power-realtime", to: "Power"},
+	{from: "power-efficiency", to: "Power Rate"},
+	{from: "power-limit", to: "Power Limit"},
+	{from: "pool-rejected", to: "Pool Rejected%"},
+	{from: "pool-stale", to: "Pool Stale%"},
+	{from: "hash-stable", to: "Hash Stable"},
+	{from: "hash-stable-cost-seconds", to: "Hash Stable Cost Seconds"},
+	{from: "hash-deviation", to: "Hash Deviation%"},
+	{from: "environment-temperature", to: "Env Temp"},
+	{from: "power-mode", to: "Power Mode"},
+	{from: "chip-temp-min", to: "Chip Temp Min"},
+	{from: "chip-temp-max", to: "Chip Temp Max"},
+	{from: "chip-temp-avg", to: "Chip Temp Avg"},
+	{from: "btminer-fast-boot", to: "Btminer Fast Boot"},
+}
+
+var poolFields = []v3Field{
+	{from: "id", to: "POOL"},
+	{from: "url", to: "URL"},
+	{from: "url", to: "Stratum URL"},
+	{from: "status", to: "Status"},
+	{from: "priority", to: "Priority"},
+	{from: "account", to: "User"},
+	{from: "accepted", to: "Accepted"},
+	{from: "rejected", to: "Rejected"},
+	{from: "stale", to: "Stale"},
+	{from: "stratum-active", to: "Stratum Active"},
+	{from: "stratum-difficulty", to: "Stratum Difficulty"},
+	{from: "last-share-time", to: "Last Share Time"},
+	{from: "reject-rate", to: "Pool Rejected%"},
+	{from: "stale-rate", to: "Pool Stale%"},
+}
+
+var edevFields = []v3Field{
+	{from: "id", to: "ASC"},
+	{from: "slot", to: "Slot"},
+	{from: "status", to: "Status"},
+	{from: "temperature", to: "Temperature"},
+	{from: "chip-frequency", to: "Chip Frequency"},
+	{from: "hash-average", to: "MHS av", scale: thToMH},
+	{from: "hash-realtime", to: "MHS 5s", scale: thToMH},
+	{from: "hash-realtime", to: "HS RT", scale: thToMH},
+	{from: "factory-hash", to: "HS Factory", scale: thToGH},
+	{from: "upfreq-complete", to: "Upfreq Complete"},
+	{from: "effective-chips", to: "Effective Chips"},
+	{from: "pcb-sn", to: "PCB SN"},
+	{from: "chip-data", to: "Chip Data"},
+	{from: "chip-temp-min", to: "Chip Temp Min"},
+	{from: "chip-temp-max", to: "Chip Temp Max"},
+	{from: "chip-temp-avg", to: "Chip Temp Avg"},
+	{from: "chip-vol-diff", to: "chip_vol_diff"},
+}
+
+var devdetailFields = []v3Field{
+	{from: "id", to: "DEVDETAILS"},
+	{from: "slot", to: "ID"},
+	{from: "model", to: "Model"},
+}
+
+var psuFields = []v3Field{
+	{from: "name", to: "name", str: true},
+	{from: "hw-version", to: "hw_version", str: true},
+	{from: "sw-version", to: "sw_version", str: true},
+	{from: "sw-version", to: "version", str: true},
+	{from: "model", to: "model", str: true},
+	{from: "iin", to: "iin", str: true},
+	{from: "vin", to: "vin", str: true},
+	{from: "pin", to: "pin", str: true},
+	{from: "fan-speed", to: "fan_speed", str: true},
+	{from: "serial-no", to: "serial_no", str: true},
+	{from: "vendor", to: "vendor", str: true},
+	{from: "temp0", to: "temp0", str: true},
+}
+
+var networkFields = []v3Field{
+	{from: "ip", to: "ip"},
+	{from: "proto", to: "proto"},
+	{from: "netmask", to: "netmask"},
+	{from: "dns", to: "dns"},
+	{from: "mac", to: "mac"},
+	{from: "gateway", to: "gateway"},
+	{from: "hostname", to: "hostname"},
+}
+
+// renameFields copies the fields of src listed in fields under their 2.x names.
+func renameFields(src map[string]any, fields []v3Field) map[string]any {
+	dst := make(map[string]any, len(fields))
+	for _, f := range fields {
+		v, ok := src[f.from]
+		if !ok {
+			continue
+		}
+		if n, ok := v.(float64); ok && f.scale != 0 {
+			v = n * f.scale
+		}
+		if f.str {
+			v = stringValue(v)
+		}
+		dst[f.to] = v
+	}
+	return dst
+}
+
+func stringValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// statusOK wraps msg in the flat 2.x envelope.
+func statusOK(r *v3Reply, msg any) map[string]any {
+	return map[string]any{
+		"STATUS":      "S",
+		"When":        r.When,
+		"Code":        CodeCommandOK,
+		"Msg":         msg,
+		"Description": r.Desc,
+	}
+}
+
+// statusList returns the STATUS list of the cgminer-style 2.x responses.
+func statusList(r *v3Reply, msg string) []map[string]any {
+	return []map[string]any{{"STATUS": "S", "When": r.When, "Code": CodeCommandOK, "Msg": msg}}
+}
+
+func nativeReply(r *v3Reply) (any, error) {
+	return r, nil
+}
+
+func commandReply(r *v3Reply) (any, error) {
+	var msg any
+	if len(r.Msg) > 0 {
+		if err := json.Unmarshal(r.Msg, &msg); err != nil {
+			return nil, err
+		}
+	}
+	return statusOK(r, msg), nil
+}
+
+// minerStatus decodes the sections of a get.miner.status reply.
+type minerStatus struct {
+	Summary map[string]any   `json:"summary"`
+	Pools   []map[string]any `json:"pools"`
+	Edevs   []map[string]any `json:"edevs"`
+}
+
+func decodeMinerStatus(r *v3Reply) (*minerStatus, error) {
+	var st minerStatus
+	if err := json.Unmarshal(r.Msg, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func summaryReply(r *v3Reply) (any, error) {
+	st, err := decodeMinerStatus(r)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"STATUS":  statusList(r, "Summary"),
+		"SUMMARY": []map[string]any{renameFields(st.Summary, summaryFields)},
+	}, nil
+}
+
+func poolsReply(r *v3Reply) (any, error) {
+	st, err := decodeMinerStatus(r)
+	if err != nil {
+		return nil, err
+	}
+	pools := make([]map[string]any, len(st.Pools))
+	for i, p := range st.Pools {
+		pools[i] = renameFields(p, poolFields)
+	}
+	return map[string]any{"STATUS": statusList(r, fmt.Sprintf("%d Pool(s)", len(pools))), "POOLS": pools}, nil
+}
+
+func edevsReply(r *v3Reply) (any, error) {
+	st, err := decodeMinerStatus(r)
+	if err != nil {
+		return nil, err
+	}
+	devs := make([]map[string]any, len(st.Edevs))
+	for i, e := range st.Edevs {
+		devs[i] = renameFields(e, edevFields)
+	}
+	return map[string]any{"STATUS": statusList(r, "EDevs"), "DEVS": devs}, nil
+}
+
+func devdetailsReply(r *v3Reply) (any, error) {
+	st, err := decodeMinerStatus(r)
+	if err != nil {
+		return nil, err
+	}
+	details := make([]map[string]any, len(st.Edevs))
+	for i, e := range st.Edevs {
+		details[i] = renameFields(e, devdetailFields)
+		details[i]["Name"] = "SM"
+		details[i]["Driver"] = "bitmicro"
+	}
+	return map[string]any{"STATUS": statusList(r, "Device Details"), "DEVDETAILS": details}, nil
+}
+
+// deviceInfo decodes the sections of a get.device.info reply.
+type deviceInfo struct {
+	System    map[string]any `json:"system"`
+	Miner     map[string]any `json:"miner"`
+	Network   map[string]any `json:"network"`
+	Power     map[string]any `json:"power"`
+	ErrorCode []any          `json:"error-code"`
+}
+
+func deviceReply(build func(r *v3Reply, info *deviceInfo) any) func(r *v3Reply) (any, error) {
+	return func(r *v3Reply) (any, error) {
+		var info deviceInfo
+		if err := json.Unmarshal(r.Msg, &info); err != nil {
+			return nil, err
+		}
+		return build(r, &info), nil
+	}
+}
+
+func psuReply(r *v3Reply, info *deviceInfo) any {
+	return statusOK(r, renameFields(info.Power, psuFields))
+}
+
+func versionReply(r *v3Reply, info *deviceInfo) any {
+	return statusOK(r, map[string]any{
+		"api_ver":  stringValue(info.System["api"]),
+		"fw_ver":   stringValue(info.System["fw-version"]),
+		"platform": stringValue(info.System["platform"]),
+		"chip":     stringValue(info.Miner["chip"]),
+	})
+}
+
+func statusReply(r *v3Reply, info *deviceInfo) any {
+	working, _ := strconv.ParseBool(stringValue(info.Miner["working"]))
+	return statusOK(r, map[string]any{
+		"btmineroff":       strconv.FormatBool(!working),
+		"Firmware Version": stringValue(info.System["fw-version"]),
+		"power_mode":       stringValue(info.Miner["power-mode"]),
+		"power_limit_set":  stringValue(info.Miner["power-limit-set"]),
+		"hash_percent":     stringValue(info.Miner["hash-percent"]),
+	})
+}
+
+func minerInfoReply(r *v3Reply, info *deviceInfo) any {
+	msg := renameFields(info.Network, networkFields)
+	msg["ledstat"] = stringValue(info.System["ledstat"])
+	return statusOK(r, msg)
+}
+
+func errorCodeReply(r *v3Reply, info *deviceInfo) any {
+	codes := info.ErrorCode
+	if codes == nil {
+		codes = []any{}
+	}
+	return statusOK(r, map[string]any{"error_code": codes})
+}
@@ -3,9 +3,10 @@
 package wmapi
 
 import (
+	"context"
 	"fmt"
 
-		"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/client"
 	"github.com/GridlessCompute/wmapi/transport"
 )
 
@@ -14,23 +15,58 @@ type WhatsminerMiddleware struct {
 	AccessToken *transport.WhatsminerAccessToken
 	Read        *client.ReadAPI
 	Write       *client.WriteAPI
+	// Protocol is the protocol the miner speaks: API itself for API 2.x firmware, or a
+	// transport.WhatsminerAPIV3 for API 3.x firmware.
+	Protocol transport.Protocol
 }
 
-func NewWhatsminerAPI(ipAddress string, port int, adminPassword string) (*WhatsminerMiddleware, error) {
-	token, err := transport.NewWhatsminerAccessToken(ipAddress, port, adminPassword)
+// NewWhatsminerAPI connects to a single miner. Any transport options (dialer, timeouts, ...) apply
+// to both the token handshake and all subsequent commands.
+func NewWhatsminerAPI(ipAddress string, port int, adminPassword string, opts ...transport.Option) (*WhatsminerMiddleware, error) {
+	return NewWhatsminerAPIContext(context.Background(), ipAddress, port, adminPassword, opts...)
+}
+
+// NewWhatsminerAPIContext is like NewWhatsminerAPI but uses ctx for the initial token handshake.
+// The miner is asked for its API version first, so firmware speaking API 3.x is handled by
+// transport.WhatsminerAPIV3 without any change for the caller; API then passes commands sent with
+// AccessToken on to it. Retries of write commands follow the idempotency recorded in the client
+// command registry. The firmware's capabilities are derived from the get_version reply read while
+// detecting the protocol, so no command is sent twice.
+func NewWhatsminerAPIContext(ctx context.Context, ipAddress string, port int, adminPassword string, opts ...transport.Option) (*WhatsminerMiddleware, error) {
+	opts = append([]transport.Option{transport.WithIdempotent(client.IsIdempotent)}, opts...)
+	proto, port, version, err := transport.Detect(ctx, ipAddress, port, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create access token: %w", err)
+		return nil, err
+	}
+	api, ok := proto.(*transport.WhatsminerAPI)
+	if !ok {
+		api = transport.NewWhatsminerAPI(opts...)
 	}
 
-	api := &transport.WhatsminerAPI{}
+	token, err := proto.NewAccessToken(ctx, ipAddress, port, adminPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access token: %w", err)
+	}
 
+	info := client.VersionInfo(*version)
 	mw := &WhatsminerMiddleware{
 		API:         api,
 		AccessToken: token,
-		Read:        &client.ReadAPI{API: api, Token: token},
-		Write:       &client.WriteAPI{API: api, Token: token},
+		Read:        &client.ReadAPI{API: api, Token: token, Protocol: proto},
+		Write:       &client.WriteAPI{API: api, Token: token, Protocol: proto, Capabilities: client.NewCapabilities(proto, &info)},
+		Protocol:    proto,
 	}
 
 	return mw, nil
 }
 
+// Capabilities returns what the miner's firmware supports, as probed when connecting. Write
+// commands it does not support fail with transport.ErrUnsupported without being sent.
+func (m *WhatsminerMiddleware) Capabilities() *client.Capabilities {
+	return m.Write.Capabilities
+}
+
+// Close stops the background refresh of the middleware's access token.
+func (m *WhatsminerMiddleware) Close() {
+	m.AccessToken.Close()
+}
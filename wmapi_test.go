@@ -0,0 +1,109 @@
+package wmapi_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi"
+	"github.com/GridlessCompute/wmapi/client"
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// TestSimulatorSmoke runs a read, an encrypted write and an injected fault through the middleware
+// against wmapisim.
+func TestSimulatorSmoke(t *testing.T) {
+	sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+	if err := sim.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	mw, err := wmapi.NewWhatsminerAPIContext(ctx, sim.Host(), sim.Port(), sim.Password())
+	if err != nil {
+		t.Fatalf("NewWhatsminerAPIContext: %v", err)
+	}
+	defer mw.Close()
+
+	t.Run("read", func(t *testing.T) {
+		summary, err := mw.Read.SummaryContext(ctx)
+		if err != nil {
+			t.Fatalf("SummaryContext: %v", err)
+		}
+		if len(summary.SUMMARY) != 1 {
+			t.Fatalf("got %d summary entries, want 1", len(summary.SUMMARY))
+		}
+		if got, want := summary.SUMMARY[0].Power, client.Float(sim.State().Power); got != want {
+			t.Errorf("Power = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("encrypted write", func(t *testing.T) {
+		if _, err := mw.Write.SwitchPowerModeContext(ctx, client.LowPower); err != nil {
+			t.Fatalf("SwitchPowerModeContext: %v", err)
+		}
+		if got := sim.State().PowerMode; got != "Low" {
+			t.Errorf("simulator power mode = %q, want Low", got)
+		}
+	})
+
+	t.Run("injected fault", func(t *testing.T) {
+		sim.InjectFault(wmapisim.Fault{Kind: wmapisim.FaultStatusError, Cmd: client.CmdSummary, Code: 14, Msg: "invalid cmd", Times: 1})
+		_, err := mw.Read.SummaryContext(ctx)
+		var minerErr *transport.MinerError
+		if !errors.As(err, &minerErr) {
+			t.Fatalf("SummaryContext error = %v, want a *transport.MinerError", err)
+		}
+		if minerErr.Code != 14 || minerErr.Cmd != client.CmdSummary {
+			t.Errorf("got code %d for %s, want 14 for %s", minerErr.Code, minerErr.Cmd, client.CmdSummary)
+		}
+		if _, err := mw.Read.SummaryContext(ctx); err != nil {
+			t.Errorf("SummaryContext after the fault cleared: %v", err)
+		}
+	})
+}
+
+// TestMiddlewareAPI3 connects to a miner speaking API 3.x and checks that the exported API field
+// still sends commands over the detected protocol, including from a ReadAPI built by the caller.
+func TestMiddlewareAPI3(t *testing.T) {
+	state := wmapisim.DefaultState()
+	state.APIVersion = "3.0.1"
+	sim := wmapisim.NewServer("admin", state)
+	if err := sim.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.StartV3(); err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	mw, err := wmapi.NewWhatsminerAPIContext(ctx, sim.Host(), sim.Port(), sim.Password(), transport.WithV3Port(sim.V3Port()))
+	if err != nil {
+		t.Fatalf("NewWhatsminerAPIContext: %v", err)
+	}
+	defer mw.Close()
+
+	if _, ok := mw.Protocol.(*transport.WhatsminerAPIV3); !ok {
+		t.Fatalf("Protocol = %T, want *transport.WhatsminerAPIV3", mw.Protocol)
+	}
+	if _, err := mw.Read.SummaryContext(ctx); err != nil {
+		t.Errorf("Read.SummaryContext: %v", err)
+	}
+	read := &client.ReadAPI{API: mw.API, Token: mw.AccessToken}
+	if _, err := read.SummaryContext(ctx); err != nil {
+		t.Errorf("SummaryContext through the API field: %v", err)
+	}
+	write := &client.WriteAPI{API: mw.API, Token: mw.AccessToken}
+	if _, err := write.SwitchPowerModeContext(ctx, client.HighPower); err != nil {
+		t.Errorf("SwitchPowerModeContext through the API field: %v", err)
+	}
+	if got := sim.State().PowerMode; got != "High" {
+		t.Errorf("simulator power mode = %q, want High", got)
+	}
+}
@@ -0,0 +1,47 @@
+// Command wmapisim runs a simulated Whatsminer on a TCP port for local development.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:4028", "address to listen on")
+	v3Addr := flag.String("v3addr", "", "address for the API 3.x service; empty disables it")
+	password := flag.String("password", "admin", "admin password")
+	flag.Parse()
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+
+	state := wmapisim.DefaultState()
+	if *v3Addr != "" {
+		state.APIVersion = "3.0.1"
+	}
+
+	log.Printf("simulated miner listening on %s", l.Addr())
+	srv := wmapisim.NewServer(*password, state)
+
+	if *v3Addr != "" {
+		l3, err := net.Listen("tcp", *v3Addr)
+		if err != nil {
+			log.Fatalf("failed to listen: %v", err)
+		}
+		log.Printf("API 3.x listening on %s", l3.Addr())
+		go func() {
+			if err := srv.ServeV3(l3); err != nil {
+				log.Fatalf("serve failed: %v", err)
+			}
+		}()
+	}
+
+	if err := srv.Serve(l); err != nil {
+		log.Fatalf("serve failed: %v", err)
+	}
+}
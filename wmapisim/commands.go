@@ -0,0 +1,454 @@
+package wmapisim
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// readCommands answer the plaintext commands.
+var readCommands = map[string]func(*State) map[string]any{
+	"summary":        summary,
+	"pools":          pools,
+	"edevs":          edevs,
+	"devdetails":     devdetails,
+	"get_psu":        psu,
+	"get_version":    version,
+	"status":         status,
+	"get_miner_info": minerInfo,
+	"get_error_code": errorCode,
+}
+
+// writeCommands execute the encrypted commands. update_pwd and factory_reset are handled by the
+// server itself because they also affect the credentials.
+var writeCommands = map[string]Handler{
+	"update_pools":              setPools,
+	"restart_btminer":           restart,
+	"power_off":                 setMining(false),
+	"power_on":                  setMining(true),
+	"set_led":                   setLED,
+	"set_low_power":             setPowerMode("Low"),
+	"set_normal_power":          setPowerMode("Normal"),
+	"set_high_power":            setPowerMode("High"),
+	"reboot":                    reboot,
+	"net_config":                netConfig,
+	"set_target_freq":           setInt("percent", -100, 100, func(st *State, v int) { st.TargetFreqPercent = v }),
+	"enable_btminer_fast_boot":  setFlag(func(st *State) *bool { return &st.FastBoot }, true),
+	"disable_btminer_fast_boot": setFlag(func(st *State) *bool { return &st.FastBoot }, false),
+	"enable_web_pools":          setFlag(func(st *State) *bool { return &st.WebPools }, true),
+	"disable_web_pools":         setFlag(func(st *State) *bool { return &st.WebPools }, false),
+	"enable_btminer_init":       setFlag(func(st *State) *bool { return &st.BTMinerInit }, true),
+	"disable_btminer_init":      setFlag(func(st *State) *bool { return &st.BTMinerInit }, false),
+	"set_hostname":              setHostname,
+	"set_power_pct":             setInt("percent", 0, 100, func(st *State, v int) { st.PowerPercent = v }),
+	"set_power_pct_v2":          setInt("percent", 0, 100, func(st *State, v int) { st.PowerPercent = v }),
+	"set_temp_offset":           setInt("temp_offset", -30, 0, func(st *State, v int) { st.TempOffset = v }),
+	"adjust_power_limit":        setInt("power_limit", 0, 99999, func(st *State, v int) { st.PowerLimit = float64(v) }),
+	"adjust_upfreq_speed":       setInt("upfreq_speed", 0, 9, func(st *State, v int) { st.UpfreqSpeed = v }),
+	"set_poweroff_cool":         setBool("poweroff_cool", func(st *State, v bool) { st.PowerOffCool = v }),
+	"set_fan_zero_speed":        setBool("fan_zero_speed", func(st *State, v bool) { st.FanZeroSpeed = v }),
+}
+
+func summary(st *State) map[string]any {
+	mhs := st.hashrateMHS()
+	var factory, tempSum, chipMin, chipMax, chipAvg float64
+	for i, b := range st.Boards {
+		factory += b.FactoryGHS
+		tempSum += b.Temperature
+		chipAvg += b.ChipTempAvg
+		if i == 0 || b.ChipTempMin < chipMin {
+			chipMin = b.ChipTempMin
+		}
+		chipMax = max(chipMax, b.ChipTempMax)
+	}
+	var temp float64
+	if n := float64(len(st.Boards)); n > 0 {
+		temp = tempSum / n
+		chipAvg /= n
+	}
+	var powerRate float64
+	if mhs > 0 {
+		powerRate = st.Power / (mhs / 1e6)
+	}
+	uptime := time.Since(st.BootTime).Seconds()
+
+	return map[string]any{
+		"STATUS": statusList("Summary"),
+		"SUMMARY": []map[string]any{{
+			"Elapsed":                  uptime,
+			"MHS av":                   mhs,
+			"MHS 5s":                   mhs,
+			"MHS 1m":                   mhs,
+			"MHS 5m":                   mhs,
+			"MHS 15m":                  mhs,
+			"HS RT":                    mhs,
+			"Accepted":                 1000,
+			"Rejected":                 2,
+			"Total MH":                 mhs * uptime,
+			"Temperature":              temp,
+			"freq_avg":                 st.FreqAvg,
+			"Fan Speed In":             st.FanSpeedIn,
+			"Fan Speed Out":            st.FanSpeedOut,
+			"Power":                    st.Power,
+			"Power Rate":               powerRate,
+			"Pool Rejected%":           0.2,
+			"Pool Stale%":              0,
+			"Last getwork":             time.Now().Unix(),
+			"Uptime":                   uptime,
+			"Security Mode":            0,
+			"Hash Stable":              st.Mining,
+			"Hash Stable Cost Seconds": 1200,
+			"Hash Deviation%":          0.1,
+			"Target Freq":              st.FreqAvg * float64(100+st.TargetFreqPercent) / 100,
+			"Target MHS":               mhs,
+			"Env Temp":                 st.EnvTemp,
+			"Power Mode":               st.PowerMode,
+			"Factory GHS":              factory,
+			"Power Limit":              st.PowerLimit,
+			"Chip Temp Min":            chipMin,
+			"Chip Temp Max":            chipMax,
+			"Chip Temp Avg":            chipAvg,
+			"Debug":                    "-0.0_100.0_0",
+			"Btminer Fast Boot":        enabled(st.FastBoot),
+		}},
+		"id": 1,
+	}
+}
+
+func pools(st *State) map[string]any {
+	list := make([]map[string]any, len(st.Pools))
+	for i, p := range st.Pools {
+		poolStatus := "Alive"
+		if !st.Mining {
+			poolStatus = "Dead"
+		}
+		list[i] = map[string]any{
+			"POOL":                  i,
+			"URL":                   p.URL,
+			"Status":                poolStatus,
+			"Priority":              i,
+			"Quota":                 1,
+			"Long Poll":             "N",
+			"Getworks":              100,
+			"Accepted":              1000,
+			"Rejected":              2,
+			"Works":                 5000,
+			"Discarded":             0,
+			"Stale":                 0,
+			"Get Failures":          0,
+			"Remote Failures":       0,
+			"User":                  p.Worker,
+			"Last Share Time":       time.Now().Unix(),
+			"Diff1 Shares":          0,
+			"Proxy Type":            "",
+			"Proxy":                 "",
+			"Difficulty Accepted":   1000 * 65536.0,
+			"Difficulty Rejected":   2 * 65536.0,
+			"Difficulty Stale":      0,
+			"Last Share Difficulty": 65536.0,
+			"Work Difficulty":       65536.0,
+			"Has Stratum":           1,
+			"Stratum Active":        i == 0 && st.Mining,
+			"Stratum URL":           p.URL,
+			"Stratum Difficulty":    65536.0,
+			"Best Share":            1 << 30,
+			"Pool Rejected%":        0.2,
+			"Pool Stale%":           0,
+			"Bad Work":              0,
+			"Current Block Height":  850000,
+			"Current Block Version": 536870912,
+		}
+	}
+	return map[string]any{"STATUS": statusList("1 Pool(s)"), "POOLS": list, "id": 1}
+}
+
+func edevs(st *State) map[string]any {
+	list := make([]map[string]any, len(st.Boards))
+	for i, b := range st.Boards {
+		mhs := b.MHS
+		if !st.Mining {
+			mhs = 0
+		}
+		list[i] = map[string]any{
+			"ASC":             i,
+			"Slot":            b.Slot,
+			"Enabled":         "Y",
+			"Status":          "Alive",
+			"Temperature":     b.Temperature,
+			"Chip Frequency":  b.ChipFrequency,
+			"MHS av":          mhs,
+			"MHS 5s":          mhs,
+			"MHS 1m":          mhs,
+			"MHS 5m":          mhs,
+			"MHS 15m":         mhs,
+			"HS RT":           mhs,
+			"HS Factory":      b.FactoryGHS,
+			"Accepted":        333,
+			"Rejected":        1,
+			"Last Valid Work": time.Now().Unix(),
+			"Upfreq Complete": 1,
+			"Effective Chips": b.EffectiveChips,
+			"PCB SN":          b.PCBSN,
+			"Chip Data":       b.ChipData,
+			"Chip Temp Min":   b.ChipTempMin,
+			"Chip Temp Max":   b.ChipTempMax,
+			"Chip Temp Avg":   b.ChipTempAvg,
+			"chip_vol_diff":   b.ChipVolDiff,
+		}
+	}
+	return map[string]any{"STATUS": statusList("EDevs"), "DEVS": list, "id": 1}
+}
+
+func devdetails(st *State) map[string]any {
+	list := make([]map[string]any, len(st.Boards))
+	for i, b := range st.Boards {
+		list[i] = map[string]any{
+			"DEVDETAILS": i,
+			"Name":       "SM",
+			"ID":         b.Slot,
+			"Driver":     "bitmicro",
+			"Kernel":     "",
+			"Model":      b.Model,
+		}
+	}
+	return map[string]any{"STATUS": statusList("Device Details"), "DEVDETAILS": list, "id": 1}
+}
+
+func psu(st *State) map[string]any {
+	return statusOK(map[string]any{
+		"name":       st.PSU.Name,
+		"hw_version": st.PSU.HwVersion,
+		"sw_version": st.PSU.SwVersion,
+		"model":      st.PSU.Model,
+		"iin":        formatFloat(st.PSU.Iin),
+		"vin":        formatFloat(st.PSU.Vin),
+		"pin":        formatFloat(st.PSU.Pin),
+		"fan_speed":  formatFloat(st.PSU.FanSpeed),
+		"version":    st.PSU.SwVersion,
+		"serial_no":  st.PSU.SerialNo,
+		"vendor":     st.PSU.Vendor,
+		"temp0":      formatFloat(st.PSU.Temp0),
+	})
+}
+
+func version(st *State) map[string]any {
+	return statusOK(map[string]any{
+		"api_ver":  st.APIVersion,
+		"fw_ver":   st.FirmwareVersion,
+		"platform": st.Platform,
+		"chip":     st.Chip,
+	})
+}
+
+func status(st *State) map[string]any {
+	return statusOK(map[string]any{
+		"btmineroff":       strconv.FormatBool(!st.Mining),
+		"Firmware Version": "'" + st.FirmwareVersion + "'",
+		"power_mode":       st.PowerMode,
+		"power_limit_set":  formatFloat(st.PowerLimit),
+		"hash_percent":     strconv.Itoa(100 + st.TargetFreqPercent),
+	})
+}
+
+func minerInfo(st *State) map[string]any {
+	ledstat := st.LED
+	if ledstat != "auto" {
+		ledstat = "manual"
+	}
+	return statusOK(map[string]any{
+		"ip":       st.Network.IP,
+		"proto":    st.Network.Proto,
+		"netmask":  st.Network.Netmask,
+		"dns":      st.Network.DNS,
+		"mac":      st.Network.MAC,
+		"ledstat":  ledstat,
+		"gateway":  st.Network.Gateway,
+		"hostname": st.Hostname,
+	})
+}
+
+func errorCode(st *State) map[string]any {
+	codes := make([]map[string]string, len(st.ErrorCodes))
+	for i, e := range st.ErrorCodes {
+		codes[i] = map[string]string{strconv.Itoa(e.Code): e.Time.Format(time.DateTime)}
+	}
+	return statusOK(map[string]any{"error_code": codes})
+}
+
+func setPools(st *State, params map[string]any) (any, error) {
+	var list []Pool
+	for i := 1; i <= 3; i++ {
+		url := stringParam(params, fmt.Sprintf("pool%d", i))
+		if url == "" {
+			continue
+		}
+		list = append(list, Pool{
+			URL:      url,
+			Worker:   stringParam(params, fmt.Sprintf("worker%d", i)),
+			Password: stringParam(params, fmt.Sprintf("passwd%d", i)),
+		})
+	}
+	if len(list) == 0 {
+		return nil, &CommandError{Code: 14, Msg: "no pool provided"}
+	}
+	st.Pools = list
+	return "API command OK", nil
+}
+
+func restart(st *State, params map[string]any) (any, error) {
+	st.Restarts++
+	return "API command OK", nil
+}
+
+func reboot(st *State, params map[string]any) (any, error) {
+	st.Reboots++
+	st.BootTime = time.Now()
+	return "API command OK", nil
+}
+
+func setMining(on bool) Handler {
+	return func(st *State, params map[string]any) (any, error) {
+		st.Mining = on
+		return "API command OK", nil
+	}
+}
+
+func setPowerMode(mode string) Handler {
+	return func(st *State, params map[string]any) (any, error) {
+		st.PowerMode = mode
+		return "API command OK", nil
+	}
+}
+
+func setLED(st *State, params map[string]any) (any, error) {
+	if mode := stringParam(params, "param"); mode != "" {
+		st.LED = mode
+		return "API command OK", nil
+	}
+	color := stringParam(params, "color")
+	if color != "red" && color != "green" {
+		return nil, &CommandError{Code: 14, Msg: "invalid color"}
+	}
+	st.LED = color
+	return "API command OK", nil
+}
+
+func netConfig(st *State, params map[string]any) (any, error) {
+	if stringParam(params, "param") == "dhcp" {
+		st.Network.Proto = "dhcp"
+		return "API command OK", nil
+	}
+
+	ip := stringParam(params, "ip")
+	if ip == "" {
+		return nil, &CommandError{Code: 14, Msg: "invalid ip"}
+	}
+	st.Network = Network{
+		IP:      ip,
+		Proto:   "static",
+		Netmask: stringParam(params, "mask"),
+		Gateway: stringParam(params, "gate"),
+		DNS:     stringParam(params, "dns"),
+		MAC:     st.Network.MAC,
+	}
+	if host := stringParam(params, "host"); host != "" {
+		st.Hostname = host
+	}
+	return "API command OK", nil
+}
+
+func setHostname(st *State, params map[string]any) (any, error) {
+	name := stringParam(params, "hostname")
+	if name == "" {
+		return nil, &CommandError{Code: 14, Msg: "invalid hostname"}
+	}
+	st.Hostname = name
+	return "API command OK", nil
+}
+
+func setFlag(field func(*State) *bool, value bool) Handler {
+	return func(st *State, params map[string]any) (any, error) {
+		*field(st) = value
+		return "API command OK", nil
+	}
+}
+
+func setInt(key string, lo, hi int, apply func(*State, int)) Handler {
+	return func(st *State, params map[string]any) (any, error) {
+		v, err := intParam(params, key)
+		if err != nil {
+			return nil, &CommandError{Code: 14, Msg: err.Error()}
+		}
+		if v < lo || v > hi {
+			return nil, &CommandError{Code: 14, Msg: fmt.Sprintf("%s out of range", key)}
+		}
+		apply(st, v)
+		return "API command OK", nil
+	}
+}
+
+func setBool(key string, apply func(*State, bool)) Handler {
+	return func(st *State, params map[string]any) (any, error) {
+		switch stringParam(params, key) {
+		case "0":
+			apply(st, false)
+		case "1":
+			apply(st, true)
+		default:
+			return nil, &CommandError{Code: 14, Msg: fmt.Sprintf("invalid %s", key)}
+		}
+		return "API command OK", nil
+	}
+}
+
+// stringParam returns params[key] as a string, formatting numbers if necessary.
+func stringParam(params map[string]any, key string) string {
+	switch v := params[key].(type) {
+	case string:
+		return v
+	case float64:
+		return formatFloat(v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// intParam accepts both numeric and string parameters, as the firmware does.
+func intParam(params map[string]any, key string) (int, error) {
+	s := stringParam(params, key)
+	if s == "" {
+		return 0, fmt.Errorf("missing %s", key)
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", key)
+	}
+	return v, nil
+}
+
+func statusList(msg string) []map[string]any {
+	return []map[string]any{{"STATUS": "S", "Msg": msg}}
+}
+
+func statusOK(msg map[string]any) map[string]any {
+	return map[string]any{
+		"STATUS":      "S",
+		"When":        time.Now().Unix(),
+		"Code":        131,
+		"Msg":         msg,
+		"Description": "",
+	}
+}
+
+func enabled(b bool) string {
+	if b {
+		return "enable"
+	}
+	return "disable"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
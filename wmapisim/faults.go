@@ -0,0 +1,134 @@
+package wmapisim
+
+import (
+	"net"
+	"regexp"
+	"time"
+)
+
+// FaultKind selects the misbehaviour injected by a Fault.
+type FaultKind int
+
+const (
+	// FaultOverMaxConnect replies with the firmware's "over max connect" refusal.
+	FaultOverMaxConnect FaultKind = iota + 1
+	// FaultNonStandardNumbers emits bare inf/nan literals in read responses, as some firmware does
+	// when a rate cannot be computed.
+	FaultNonStandardNumbers
+	// FaultTruncate sends only the first After bytes of the response, then closes cleanly.
+	FaultTruncate
+	// FaultSlowLoris sends the response one byte at a time, waiting Delay between bytes.
+	FaultSlowLoris
+	// FaultReset sends the first After bytes of the response, then resets the connection.
+	FaultReset
+	// FaultStatusError replies with STATUS "E" and the given Code and Msg instead of executing
+	// the command.
+	FaultStatusError
+)
+
+// Fault describes a misbehaviour to inject into matching requests.
+type Fault struct {
+	Kind FaultKind
+	// Cmd restricts the fault to a single command; empty matches every command including get_token.
+	Cmd string
+	// Times is how many matching requests are affected before the fault clears itself. Zero means
+	// the fault stays until ClearFaults is called.
+	Times int
+
+	// Code and Msg are used by FaultStatusError.
+	Code int
+	Msg  string
+	// After is the number of response bytes sent by FaultTruncate and FaultReset. Zero means half
+	// of the response.
+	After int
+	// Delay is the pause between bytes for FaultSlowLoris.
+	Delay time.Duration
+}
+
+// InjectFault adds f to the active faults. Faults are evaluated in the order they were added and
+// several may apply to the same request.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all active faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// ExpireTokens invalidates every token issued so far, as if they had all reached their lifetime.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.tokens)
+}
+
+// takeFault returns the first active fault of kind matching cmd, consuming one of its uses.
+// The caller must hold the mutex.
+func (s *Server) takeFault(kind FaultKind, cmd string) *Fault {
+	for i, f := range s.faults {
+		if f.Kind != kind || (f.Cmd != "" && f.Cmd != cmd) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// nonStandardFields are replaced with bare inf/nan literals by FaultNonStandardNumbers.
+var nonStandardFields = regexp.MustCompile(`"(Power Rate|Hash Deviation%|chip_vol_diff)":-?[0-9.eE+-]+`)
+
+// injectNonStandardNumbers rewrites a few numeric fields the way misbehaving firmware reports them.
+func injectNonStandardNumbers(resp []byte) []byte {
+	return nonStandardFields.ReplaceAllFunc(resp, func(m []byte) []byte {
+		sub := nonStandardFields.FindSubmatch(m)
+		literal := "nan"
+		if string(sub[1]) == "Power Rate" {
+			literal = "inf"
+		}
+		return append([]byte(`"`+string(sub[1])+`":`), literal...)
+	})
+}
+
+// writeResponse sends resp, applying any connection-level fault.
+func writeResponse(conn net.Conn, resp []byte, f *Fault) {
+	if f == nil {
+		conn.Write(resp)
+		return
+	}
+
+	after := f.After
+	if after <= 0 || after > len(resp) {
+		after = len(resp) / 2
+	}
+
+	switch f.Kind {
+	case FaultTruncate:
+		conn.Write(resp[:after])
+	case FaultReset:
+		conn.Write(resp[:after])
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			tcp.SetLinger(0)
+		}
+	case FaultSlowLoris:
+		conn.SetWriteDeadline(time.Time{})
+		for i := range resp {
+			if _, err := conn.Write(resp[i : i+1]); err != nil {
+				return
+			}
+			time.Sleep(f.Delay)
+		}
+	default:
+		conn.Write(resp)
+	}
+}
@@ -0,0 +1,75 @@
+package wmapisim
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// maxFirmwareSize bounds the images the simulator accepts.
+const maxFirmwareSize = 512 << 20
+
+// firmwareTimeout bounds how long a client may take to upload an image.
+const firmwareTimeout = 5 * time.Minute
+
+// receiveFirmware reads the length-prefixed image that follows an accepted update_firmware
+// command, installs it and reboots: the firmware version becomes "sim-" followed by a hash of the
+// image and connections are refused for FirmwareInstallTime. With RejectFirmware the image is
+// discarded instead.
+func (s *Server) receiveFirmware(conn net.Conn, r io.Reader) {
+	conn.SetDeadline(time.Now().Add(firmwareTimeout))
+
+	// Skip the newline that terminated the command.
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(1); err == nil && b[0] == '\n' {
+		br.Discard(1)
+	}
+	r = br
+
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return
+	}
+	size := binary.LittleEndian.Uint32(header[:])
+	if size == 0 || size > maxFirmwareSize {
+		conn.Write(statusError(132, "invalid firmware size"))
+		return
+	}
+	image := make([]byte, size)
+	if _, err := io.ReadFull(r, image); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.RejectFirmware {
+		s.mu.Unlock()
+		return
+	}
+	_, block, err := s.cipher()
+	if err != nil {
+		s.mu.Unlock()
+		conn.Write(statusError(132, err.Error()))
+		return
+	}
+	now := time.Now()
+	s.state.FirmwareVersion = fmt.Sprintf("sim-%x", sha256.Sum256(image))[:12]
+	s.state.Reboots++
+	s.state.BootTime = now
+	s.downUntil = now.Add(s.FirmwareInstallTime)
+	clear(s.tokens)
+	resp := encryptedOK(block, "firmware update ok")
+	s.mu.Unlock()
+
+	conn.Write(resp)
+}
+
+// rebooting reports whether the simulated miner is still installing firmware.
+func (s *Server) rebooting() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Now().Before(s.downUntil)
+}
@@ -0,0 +1,51 @@
+package wmapisim
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// defaultLogs returns a btminer and a kernel log of a miner that booted at boot.
+func defaultLogs(boot time.Time) map[string]string {
+	boot = boot.UTC()
+	stamp := func(d time.Duration) string { return boot.Add(d).Format("2006-01-02 15:04:05.000") }
+	syslog := func(d time.Duration) string { return boot.Add(d).Format("Jan _2 15:04:05") }
+
+	return map[string]string{
+		"var/log/btminer.log": fmt.Sprintf("%s [info] btminer starting\n%s [info] 3 hashboards detected\n%s [warn] pool 0 connection lost, retrying\n",
+			stamp(10*time.Second), stamp(12*time.Second), stamp(5*time.Minute)),
+		"var/log/kern.log": fmt.Sprintf("%s WhatsMiner kernel: Booting Linux\n%s WhatsMiner kernel: eth0: link up\n",
+			syslog(0), syslog(3*time.Second)),
+		"var/log/boot.log": "no timestamps here\n",
+	}
+}
+
+// logBundle returns Logs as a gzip-compressed tarball.
+func (s *State) logBundle() ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, name := range slices.Sorted(maps.Keys(s.Logs)) {
+		content := s.Logs[name]
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,508 @@
+// Package wmapisim implements a local stand-in for a Whatsminer ASIC. It speaks the same TCP
+// protocol as the firmware (plaintext read commands, get_token and AES-ECB encrypted write
+// commands), so transport, client and wmapi can be exercised without real hardware.
+package wmapisim
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GehirnInc/crypt/md5_crypt"
+	"github.com/andreburgaud/crypt2go/ecb"
+)
+
+// DefaultTokenLifetime matches how long the firmware honours a signed token.
+const DefaultTokenLifetime = 30 * time.Minute
+
+// requestTimeout bounds how long a client may take to send its request.
+const requestTimeout = 10 * time.Second
+
+// Handler executes a command against the miner state and returns the value reported in "Msg".
+// It is called with the server lock held, so it may freely read and modify st.
+type Handler func(st *State, params map[string]any) (any, error)
+
+// CommandError is returned by a Handler to make the miner reply with STATUS "E".
+type CommandError struct {
+	Code int
+	Msg  string
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("code %d: %s", e.Code, e.Msg)
+}
+
+// Server is a simulated miner. Create one with NewServer, then call Start or Serve.
+type Server struct {
+	// TokenLifetime is how long a signed token stays valid. Zero means DefaultTokenLifetime.
+	TokenLifetime time.Duration
+	// FirmwareInstallTime is how long the server refuses connections after a firmware upload, as
+	// the miner would while it installs the image and reboots.
+	FirmwareInstallTime time.Duration
+	// RejectFirmware makes the server discard uploaded images and close the connection without
+	// a reply, as the miner does with an image that fails verification. It keeps running the
+	// installed firmware and does not reboot.
+	RejectFirmware bool
+
+	mu              sync.Mutex
+	password        string
+	initialPassword string
+	salt            string
+	state           State
+	initial         State
+	tokens          map[string]time.Time
+	reads           map[string]func(*State) map[string]any
+	writes          map[string]Handler
+	faults          []*Fault
+	downUntil       time.Time
+	logs            []byte // bundle announced by the last download_logs
+	received        map[string]int
+
+	listener   net.Listener
+	v3Listener net.Listener
+	wg         sync.WaitGroup
+}
+
+// NewServer creates a simulated miner with the given admin password and initial state.
+func NewServer(password string, state State) *Server {
+	return &Server{
+		password:        password,
+		initialPassword: password,
+		salt:            randomSalt(),
+		state:           state.clone(),
+		initial:         state.clone(),
+		tokens:          make(map[string]time.Time),
+		received:        make(map[string]int),
+		reads:           maps.Clone(readCommands),
+		writes:          maps.Clone(writeCommands),
+	}
+}
+
+// Start listens on a random loopback port and serves in the background.
+func (s *Server) Start() error {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	s.setListener(l)
+	go s.serve(l)
+	return nil
+}
+
+// Serve accepts connections on l until Close is called.
+func (s *Server) Serve(l net.Listener) error {
+	s.setListener(l)
+	return s.serve(l)
+}
+
+func (s *Server) setListener(l net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listener = l
+}
+
+func (s *Server) serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn)
+		}()
+	}
+}
+
+// Close stops accepting connections and waits for in-flight requests to finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	l, v3 := s.listener, s.v3Listener
+	s.mu.Unlock()
+
+	var err error
+	if l != nil {
+		err = l.Close()
+	}
+	if v3 != nil {
+		v3.Close()
+	}
+	s.wg.Wait()
+	return err
+}
+
+// Addr returns the address the server is listening on, or nil before Start/Serve.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Host returns the IP address the server is listening on.
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.Addr().String())
+	return host
+}
+
+// Port returns the TCP port the server is listening on.
+func (s *Server) Port() int {
+	_, port, _ := net.SplitHostPort(s.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return p
+}
+
+// Password returns the current admin password, which update_pwd may have changed.
+func (s *Server) Password() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.password
+}
+
+// State returns a copy of the current miner state.
+func (s *Server) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.clone()
+}
+
+// SetState replaces the miner state.
+func (s *Server) SetState(st State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = st.clone()
+}
+
+// Update applies fn to the miner state under the server lock.
+func (s *Server) Update(fn func(st *State)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.state)
+}
+
+// Received returns how many requests for cmd the server has received, including ones answered
+// by an injected fault. Write commands are counted once they have been decrypted.
+func (s *Server) Received(cmd string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.received[cmd]
+}
+
+// Handle registers h for a write command, replacing any built-in behaviour.
+func (s *Server) Handle(cmd string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes[cmd] = h
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	if s.rebooting() {
+		return
+	}
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	dec := json.NewDecoder(conn)
+	var req map[string]any
+	if err := dec.Decode(&req); err != nil {
+		return
+	}
+
+	var resp []byte
+	var cmd string
+	if data, ok := req["data"].(string); ok && req["enc"] != nil {
+		resp, cmd = s.handleEncrypted(data)
+		if cmd == "update_firmware" && bytes.HasPrefix(resp, []byte(`{"enc"`)) {
+			// The image follows on the same connection once the miner has answered "ready".
+			conn.Write(resp)
+			s.receiveFirmware(conn, io.MultiReader(dec.Buffered(), conn))
+			return
+		}
+		if cmd == "download_logs" && bytes.HasPrefix(resp, []byte(`{"enc"`)) {
+			// The bundle follows the reply on the same connection.
+			s.mu.Lock()
+			logs := s.logs
+			s.mu.Unlock()
+			conn.Write(resp)
+			conn.Write(logs)
+			return
+		}
+	} else {
+		cmd, _ = req["cmd"].(string)
+		resp = s.handlePlain(cmd, req)
+	}
+
+	writeResponse(conn, resp, s.connFault(cmd))
+}
+
+// connFault returns the connection-level fault to apply to the response for cmd, if any.
+func (s *Server) connFault(cmd string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, kind := range []FaultKind{FaultTruncate, FaultReset, FaultSlowLoris} {
+		if f := s.takeFault(kind, cmd); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// replyFault returns the canned reply for cmd if a fault replaces it. The caller must hold the mutex.
+func (s *Server) replyFault(cmd string) []byte {
+	if f := s.takeFault(FaultOverMaxConnect, cmd); f != nil {
+		return statusError(0, "over max connect")
+	}
+	if f := s.takeFault(FaultStatusError, cmd); f != nil {
+		return statusError(f.Code, f.Msg)
+	}
+	return nil
+}
+
+func (s *Server) handlePlain(cmd string, params map[string]any) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.received[cmd]++
+	if resp := s.replyFault(cmd); resp != nil {
+		return resp
+	}
+
+	if cmd == "get_token" {
+		return s.issueToken()
+	}
+
+	read, ok := s.reads[cmd]
+	if !ok {
+		return statusError(14, "invalid cmd")
+	}
+	resp := mustMarshal(read(&s.state))
+	if s.takeFault(FaultNonStandardNumbers, cmd) != nil {
+		resp = injectNonStandardNumbers(resp)
+	}
+	return resp
+}
+
+func (s *Server) handleEncrypted(data string) ([]byte, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, block, err := s.cipher()
+	if err != nil {
+		return statusError(23, "invalid json"), ""
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return statusError(23, "invalid json"), ""
+	}
+	plaintext := make([]byte, len(ciphertext))
+	ecb.NewECBDecrypter(block).CryptBlocks(plaintext, ciphertext)
+	plaintext = []byte(strings.TrimRight(string(plaintext), "\x00"))
+
+	var req map[string]any
+	if err := json.Unmarshal(plaintext, &req); err != nil {
+		return statusError(23, "invalid json"), ""
+	}
+
+	cmd, _ := req["cmd"].(string)
+	s.received[cmd]++
+	if resp := s.replyFault(cmd); resp != nil {
+		return resp, cmd
+	}
+
+	sign, _ := req["token"].(string)
+	if !s.validToken(sign) {
+		return statusError(135, "check token err"), cmd
+	}
+
+	delete(req, "cmd")
+	delete(req, "token")
+
+	// Failures are reported in plaintext; only successful replies are encrypted.
+	msg, err := s.execute(cmd, req)
+	if err != nil {
+		var cmdErr *CommandError
+		if !errors.As(err, &cmdErr) {
+			cmdErr = &CommandError{Code: 14, Msg: err.Error()}
+		}
+		return statusError(cmdErr.Code, cmdErr.Msg), cmd
+	}
+
+	return encryptedOK(block, msg), cmd
+}
+
+// encryptedOK returns a successful encrypted reply carrying msg.
+func encryptedOK(block cipher.Block, msg any) []byte {
+	resp := mustMarshal(map[string]any{
+		"STATUS":      "S",
+		"When":        time.Now().Unix(),
+		"Code":        131,
+		"Msg":         msg,
+		"Description": "",
+	})
+	return mustMarshal(map[string]any{"enc": encrypt(block, resp)})
+}
+
+// execute runs a write command. The caller must hold the mutex.
+func (s *Server) execute(cmd string, params map[string]any) (any, error) {
+	switch cmd {
+	case "update_pwd":
+		old, _ := params["old"].(string)
+		newPwd, _ := params["new"].(string)
+		if old != s.password {
+			return nil, &CommandError{Code: 132, Msg: "old password error"}
+		}
+		if newPwd == "" || len(newPwd) > 8 {
+			return nil, &CommandError{Code: 132, Msg: "new password invalid"}
+		}
+		s.password = newPwd
+		clear(s.tokens)
+		return "API command OK", nil
+	case "factory_reset":
+		resets := s.state.FactoryResets + 1
+		s.state = s.initial.clone()
+		s.state.FactoryResets = resets
+		s.password = s.initialPassword
+		clear(s.tokens)
+		return "API command OK", nil
+	case "update_firmware":
+		return "ready", nil
+	case "download_logs":
+		logs, err := s.state.logBundle()
+		if err != nil {
+			return nil, err
+		}
+		s.logs = logs
+		return map[string]any{"logfilelen": strconv.Itoa(len(logs))}, nil
+	}
+
+	h, ok := s.writes[cmd]
+	if !ok {
+		return nil, &CommandError{Code: 14, Msg: "invalid cmd"}
+	}
+	return h(&s.state, params)
+}
+
+// issueToken answers get_token. The caller must hold the mutex.
+func (s *Server) issueToken() []byte {
+	key, _, err := s.cipher()
+	if err != nil {
+		return statusError(14, err.Error())
+	}
+
+	newsalt := randomSalt()
+	now := time.Now()
+	tokenTime := strconv.FormatInt(now.Unix()%10000, 10)
+
+	signHash, err := md5_crypt.New().Generate([]byte(key+tokenTime), []byte("$1$"+newsalt+"$"))
+	if err != nil {
+		return statusError(14, err.Error())
+	}
+	s.tokens[strings.Split(signHash, "$")[3]] = now
+
+	return mustMarshal(map[string]any{
+		"STATUS": "S",
+		"When":   now.Unix(),
+		"Code":   134,
+		"Msg": map[string]any{
+			"time":    tokenTime,
+			"salt":    s.salt,
+			"newsalt": newsalt,
+		},
+		"Description": "",
+	})
+}
+
+// validToken reports whether sign belongs to an unexpired token. The caller must hold the mutex.
+func (s *Server) validToken(sign string) bool {
+	issued, ok := s.tokens[sign]
+	if !ok {
+		return false
+	}
+	lifetime := s.TokenLifetime
+	if lifetime <= 0 {
+		lifetime = DefaultTokenLifetime
+	}
+	if time.Since(issued) > lifetime {
+		delete(s.tokens, sign)
+		return false
+	}
+	return true
+}
+
+// cipher derives the AES key from the admin password the same way the firmware does.
+// The caller must hold the mutex.
+func (s *Server) cipher() (string, cipher.Block, error) {
+	hash, err := md5_crypt.New().Generate([]byte(s.password), []byte("$1$"+s.salt+"$"))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	key := strings.Split(hash, "$")[3]
+	aesKey := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(aesKey[:])
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return key, block, nil
+}
+
+// encrypt PKCS#7-pads plaintext, encrypts it with AES-ECB and base64-encodes the result.
+func encrypt(block cipher.Block, plaintext []byte) string {
+	pad := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(plaintext, make([]byte, pad)...)
+	for i := len(plaintext); i < len(padded); i++ {
+		padded[i] = byte(pad)
+	}
+	ciphertext := make([]byte, len(padded))
+	ecb.NewECBEncrypter(block).CryptBlocks(ciphertext, padded)
+	return base64.StdEncoding.EncodeToString(ciphertext)
+}
+
+func statusError(code int, msg string) []byte {
+	return mustMarshal(map[string]any{
+		"STATUS":      "E",
+		"When":        time.Now().Unix(),
+		"Code":        code,
+		"Msg":         msg,
+		"Description": "",
+	})
+}
+
+func mustMarshal(v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("wmapisim: failed to marshal response: %v", err))
+	}
+	return b
+}
+
+// randomSalt returns an 8 character md5-crypt salt.
+func randomSalt() string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, 8)
+	rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return string(b)
+}
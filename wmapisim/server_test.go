@@ -0,0 +1,57 @@
+package wmapisim_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GridlessCompute/wmapi/transport"
+	"github.com/GridlessCompute/wmapi/wmapisim"
+)
+
+// TestServerRoundTrip sends a plaintext read and an encrypted write to the simulator through the
+// transport package.
+func TestServerRoundTrip(t *testing.T) {
+	sim := wmapisim.NewServer("admin", wmapisim.DefaultState())
+	if err := sim.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	api := transport.NewWhatsminerAPI()
+
+	t.Run("plaintext read", func(t *testing.T) {
+		token, err := api.NewAccessToken(ctx, sim.Host(), sim.Port(), "")
+		if err != nil {
+			t.Fatalf("NewAccessToken: %v", err)
+		}
+		resp, err := api.GetReadOnlyInfoContext(ctx, token, "get_version", nil)
+		if err != nil {
+			t.Fatalf("get_version: %v", err)
+		}
+		msg, _ := resp["Msg"].(map[string]any)
+		if got, want := msg["fw_ver"], sim.State().FirmwareVersion; got != want {
+			t.Errorf("fw_ver = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("encrypted write", func(t *testing.T) {
+		token, err := api.NewAccessToken(ctx, sim.Host(), sim.Port(), sim.Password())
+		if err != nil {
+			t.Fatalf("NewAccessToken: %v", err)
+		}
+		defer token.Close()
+		resp, err := api.ExecCommandContext(ctx, token, "set_low_power", nil)
+		if err != nil {
+			t.Fatalf("set_low_power: %v", err)
+		}
+		if resp["STATUS"] != "S" {
+			t.Errorf("STATUS = %v, want S", resp["STATUS"])
+		}
+		if got := sim.State().PowerMode; got != "Low" {
+			t.Errorf("simulator power mode = %q, want Low", got)
+		}
+	})
+}
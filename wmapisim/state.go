@@ -0,0 +1,200 @@
+package wmapisim
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// State is the scriptable state of a simulated miner. Read commands report it and write commands
+// mutate it, so tests can assert on the effect of every WriteAPI call.
+type State struct {
+	// Mining is false after power_off and true after power_on.
+	Mining    bool
+	PowerMode string // "Low", "Normal" or "High"
+
+	EnvTemp     float64
+	FanSpeedIn  float64
+	FanSpeedOut float64
+	Power       float64 // W
+	PowerLimit  float64 // W
+	FreqAvg     float64 // MHz
+
+	PowerPercent      int
+	TargetFreqPercent int
+	TempOffset        int
+	UpfreqSpeed       int
+	PowerOffCool      bool
+	FanZeroSpeed      bool
+	FastBoot          bool
+	WebPools          bool
+	BTMinerInit       bool
+	LED               string
+
+	Hostname string
+	Network  Network
+	Pools    []Pool
+	Boards   []Board
+	PSU      PSU
+
+	FirmwareVersion string
+	APIVersion      string
+	Platform        string
+	Chip            string
+
+	ErrorCodes []ErrorCode
+
+	// Logs is the content of the bundle sent for download_logs, by path inside the tarball.
+	Logs map[string]string
+
+	// Counters for commands that have no other observable effect.
+	Restarts      int
+	Reboots       int
+	FactoryResets int
+
+	BootTime time.Time
+}
+
+// Network mirrors the fields reported by get_miner_info and set by net_config.
+type Network struct {
+	IP      string
+	Proto   string // "dhcp" or "static"
+	Netmask string
+	Gateway string
+	DNS     string
+	MAC     string
+}
+
+// Pool is a configured mining pool.
+type Pool struct {
+	URL      string
+	Worker   string
+	Password string
+}
+
+// Board is a single hashboard as reported by edevs and devdetails.
+type Board struct {
+	Slot           int
+	MHS            float64 // current hashrate in MH/s
+	FactoryGHS     float64
+	Temperature    float64
+	ChipFrequency  float64
+	EffectiveChips int
+	PCBSN          string
+	ChipData       string
+	ChipTempMin    float64
+	ChipTempMax    float64
+	ChipTempAvg    float64
+	ChipVolDiff    float64
+	Model          string
+}
+
+// PSU mirrors the fields reported by get_psu.
+type PSU struct {
+	Name      string
+	Model     string
+	HwVersion string
+	SwVersion string
+	SerialNo  string
+	Vendor    string
+	Iin       float64
+	Vin       float64
+	Pin       float64
+	FanSpeed  float64
+	Temp0     float64
+}
+
+// ErrorCode is an entry reported by get_error_code.
+type ErrorCode struct {
+	Code int
+	Time time.Time
+}
+
+// DefaultState returns the state of a healthy three-board miner.
+func DefaultState() State {
+	boards := make([]Board, 3)
+	for i := range boards {
+		boards[i] = Board{
+			Slot:           i,
+			MHS:            29_500_000,
+			FactoryGHS:     30_000,
+			Temperature:    68,
+			ChipFrequency:  600,
+			EffectiveChips: 148,
+			PCBSN:          "HEM1A11M9H" + string(rune('A'+i)),
+			ChipData:       "K88Z315-2230 BINV01-195B",
+			ChipTempMin:    64,
+			ChipTempMax:    82,
+			ChipTempAvg:    73,
+			ChipVolDiff:    3,
+			Model:          "M30S+",
+		}
+	}
+
+	return State{
+		Mining:      true,
+		PowerMode:   "Normal",
+		EnvTemp:     25,
+		FanSpeedIn:  4800,
+		FanSpeedOut: 4810,
+		Power:       3300,
+		PowerLimit:  3600,
+		FreqAvg:     600,
+		WebPools:    true,
+		BTMinerInit: true,
+		LED:         "auto",
+		Hostname:    "WhatsMiner",
+		Network: Network{
+			IP:      "127.0.0.1",
+			Proto:   "dhcp",
+			Netmask: "255.255.255.0",
+			Gateway: "127.0.0.254",
+			DNS:     "127.0.0.254",
+			MAC:     "C4:07:00:00:00:01",
+		},
+		Pools: []Pool{
+			{URL: "stratum+tcp://pool.example.com:3333", Worker: "sim.worker1", Password: "x"},
+		},
+		Boards: boards,
+		PSU: PSU{
+			Name:      "P221B",
+			Model:     "P221B",
+			HwVersion: "V01.00",
+			SwVersion: "V01.00.V01.03",
+			SerialNo:  "SIM000000001",
+			Vendor:    "1",
+			Iin:       14.5,
+			Vin:       228,
+			Pin:       3320,
+			FanSpeed:  6000,
+			Temp0:     45,
+		},
+		FirmwareVersion: "20240101.22.REL",
+		APIVersion:      "2.0.5",
+		Platform:        "H6OS",
+		Chip:            "K88Z315",
+		BootTime:        time.Now(),
+		Logs:            defaultLogs(time.Now()),
+	}
+}
+
+// clone returns a deep copy of s.
+func (s State) clone() State {
+	s.Pools = slices.Clone(s.Pools)
+	s.Boards = slices.Clone(s.Boards)
+	s.ErrorCodes = slices.Clone(s.ErrorCodes)
+	s.Logs = maps.Clone(s.Logs)
+	return s
+}
+
+// hashrateMHS returns the combined hashrate of all boards, or zero when mining is stopped.
+func (s *State) hashrateMHS() float64 {
+	if !s.Mining {
+		return 0
+	}
+	var total float64
+	for _, b := range s.Boards {
+		total += b.MHS
+	}
+	return total
+}
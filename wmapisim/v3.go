@@ -0,0 +1,383 @@
+package wmapisim
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// StartV3 additionally serves the API 3.x protocol (length-prefixed JSON, get.*/set.* commands and
+// SHA-256 write tokens) on another random loopback port. Both protocols share the same state and
+// credentials. Set State.APIVersion to a 3.x version so get_version tells clients to switch.
+func (s *Server) StartV3() error {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	s.setV3Listener(l)
+	go s.serveV3(l)
+	return nil
+}
+
+// ServeV3 accepts API 3.x connections on l until Close is called.
+func (s *Server) ServeV3(l net.Listener) error {
+	s.setV3Listener(l)
+	return s.serveV3(l)
+}
+
+func (s *Server) setV3Listener(l net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.v3Listener = l
+}
+
+func (s *Server) serveV3(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleV3Conn(conn)
+		}()
+	}
+}
+
+// V3Port returns the port of the API 3.x service, or 0 before StartV3.
+func (s *Server) V3Port() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.v3Listener == nil {
+		return 0
+	}
+	return s.v3Listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *Server) handleV3Conn(conn net.Conn) {
+	defer conn.Close()
+
+	if s.rebooting() {
+		return
+	}
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var header [4]byte
+	if _, err := io.ReadFull(conn, header[:]); err != nil {
+		return
+	}
+	body := make([]byte, binary.LittleEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return
+	}
+
+	var req map[string]any
+	var resp []byte
+	cmd := ""
+	if err := json.Unmarshal(body, &req); err != nil {
+		resp = v3Reply(-3, "invalid json", "")
+	} else {
+		cmd, _ = req["cmd"].(string)
+		resp = s.handleV3(cmd, req)
+	}
+
+	frame := binary.LittleEndian.AppendUint32(nil, uint32(len(resp)))
+	writeResponse(conn, append(frame, resp...), s.connFault(cmd))
+}
+
+func (s *Server) handleV3(cmd string, req map[string]any) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.received[cmd]++
+	switch cmd {
+	case "get.device.info":
+		if req["param"] == "salt" {
+			return v3Reply(0, map[string]any{"salt": s.salt}, cmd)
+		}
+		return v3Reply(0, v3DeviceInfo(&s.state), cmd)
+	case "get.miner.status":
+		return v3Reply(0, v3MinerStatus(&s.state), cmd)
+	}
+
+	translate, ok := v3Writes[cmd]
+	if !ok {
+		return v3Reply(-2, "invalid cmd", cmd)
+	}
+	if !s.validV3Token(cmd, req) {
+		return v3Reply(-4, "invalid token", cmd)
+	}
+
+	v2Cmd, params := translate(req["param"])
+	msg, err := s.execute(v2Cmd, params)
+	if err != nil {
+		var cmdErr *CommandError
+		if !errors.As(err, &cmdErr) || cmdErr.Code == 14 {
+			return v3Reply(-2, err.Error(), cmd)
+		}
+		return v3Reply(-1, cmdErr.Msg, cmd)
+	}
+	return v3Reply(0, msg, cmd)
+}
+
+// validV3Token checks the token of a set.* request. The caller must hold the mutex.
+func (s *Server) validV3Token(cmd string, req map[string]any) bool {
+	ts, ok := req["ts"].(float64)
+	if !ok || req["account"] != "super" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(cmd + s.password + s.salt + strconv.FormatInt(int64(ts), 10)))
+	return req["token"] == base64.StdEncoding.EncodeToString(sum[:])[:8]
+}
+
+func v3Reply(code int, msg any, cmd string) []byte {
+	return mustMarshal(map[string]any{
+		"code": code,
+		"when": time.Now().Unix(),
+		"msg":  msg,
+		"desc": cmd,
+	})
+}
+
+func v3DeviceInfo(st *State) map[string]any {
+	ledstat := st.LED
+	if ledstat != "auto" {
+		ledstat = "manual"
+	}
+	codes := make([]map[string]string, len(st.ErrorCodes))
+	for i, e := range st.ErrorCodes {
+		codes[i] = map[string]string{strconv.Itoa(e.Code): e.Time.Format(time.DateTime)}
+	}
+	return map[string]any{
+		"system": map[string]any{
+			"api":        st.APIVersion,
+			"platform":   st.Platform,
+			"fw-version": st.FirmwareVersion,
+			"ledstat":    ledstat,
+		},
+		"miner": map[string]any{
+			"chip":            st.Chip,
+			"working":         strconv.FormatBool(st.Mining),
+			"power-mode":      st.PowerMode,
+			"power-limit-set": formatFloat(st.PowerLimit),
+			"hash-percent":    strconv.Itoa(100 + st.TargetFreqPercent),
+		},
+		"network": map[string]any{
+			"ip":       st.Network.IP,
+			"proto":    st.Network.Proto,
+			"netmask":  st.Network.Netmask,
+			"gateway":  st.Network.Gateway,
+			"dns":      st.Network.DNS,
+			"mac":      st.Network.MAC,
+			"hostname": st.Hostname,
+		},
+		"power": map[string]any{
+			"name":       st.PSU.Name,
+			"hw-version": st.PSU.HwVersion,
+			"sw-version": st.PSU.SwVersion,
+			"model":      st.PSU.Model,
+			"iin":        st.PSU.Iin,
+			"vin":        st.PSU.Vin,
+			"pin":        st.PSU.Pin,
+			"fan-speed":  st.PSU.FanSpeed,
+			"serial-no":  st.PSU.SerialNo,
+			"vendor":     st.PSU.Vendor,
+			"temp0":      st.PSU.Temp0,
+		},
+		"error-code": codes,
+	}
+}
+
+// v3MinerStatus reports the 2.x summary, pools and edevs under their 3.x names, with hash rates
+// in TH/s.
+func v3MinerStatus(st *State) map[string]any {
+	sum := summary(st)["SUMMARY"].([]map[string]any)[0]
+	poolList := pools(st)["POOLS"].([]map[string]any)
+	devList := edevs(st)["DEVS"].([]map[string]any)
+
+	v3Pools := make([]map[string]any, len(poolList))
+	for i, p := range poolList {
+		v3Pools[i] = renameKeys(p, v3PoolKeys)
+	}
+	v3Devs := make([]map[string]any, len(devList))
+	for i, d := range devList {
+		v3Devs[i] = renameKeys(d, v3EdevKeys)
+		v3Devs[i]["model"] = st.Boards[i].Model
+	}
+
+	return map[string]any{
+		"summary": renameKeys(sum, v3SummaryKeys),
+		"pools":   v3Pools,
+		"edevs":   v3Devs,
+	}
+}
+
+// v3Key is the 3.x name of a 2.x field. Hash rates are divided by div to get TH/s.
+type v3Key struct {
+	name string
+	div  float64
+}
+
+var v3SummaryKeys = map[string]v3Key{
+	"Elapsed":                  {name: "elapsed"},
+	"Uptime":                   {name: "bootup-time"},
+	"MHS av":                   {name: "hash-average", div: 1e6},
+	"HS RT":                    {name: "hash-realtime", div: 1e6},
+	"MHS 1m":                   {name: "hash-1min", div: 1e6},
+	"MHS 5m":                   {name: "hash-5min", div: 1e6},
+	"MHS 15m":                  {name: "hash-15min", div: 1e6},
+	"Target MHS":               {name: "target-hash", div: 1e6},
+	"Factory GHS":              {name: "factory-hash", div: 1e3},
+	"Accepted":                 {name: "accepted"},
+	"Rejected":                 {name: "rejected"},
+	"Temperature":              {name: "temperature"},
+	"freq_avg":                 {name: "freq-avg"},
+	"Target Freq":              {name: "target-freq"},
+	"Fan Speed In":             {name: "fan-speed-in"},
+	"Fan Speed Out":            {name: "fan-speed-out"},
+	"Power":                    {name: "power-realtime"},
+	"Power Rate":               {name: "power-efficiency"},
+	"Power Limit":              {name: "power-limit"},
+	"Pool Rejected%":           {name: "pool-rejected"},
+	"Pool Stale%":              {name: "pool-stale"},
+	"Hash Stable":              {name: "hash-stable"},
+	"Hash Stable Cost Seconds": {name: "hash-stable-cost-seconds"},
+	"Hash Deviation%":          {name: "hash-deviation"},
+	"Env Temp":                 {name: "environment-temperature"},
+	"Power Mode":               {name: "power-mode"},
+	"Chip Temp Min":            {name: "chip-temp-min"},
+	"Chip Temp Max":            {name: "chip-temp-max"},
+	"Chip Temp Avg":            {name: "chip-temp-avg"},
+	"Btminer Fast Boot":        {name: "btminer-fast-boot"},
+}
+
+var v3PoolKeys = map[string]v3Key{
+	"POOL":               {name: "id"},
+	"URL":                {name: "url"},
+	"Status":             {name: "status"},
+	"Priority":           {name: "priority"},
+	"User":               {name: "account"},
+	"Accepted":           {name: "accepted"},
+	"Rejected":           {name: "rejected"},
+	"Stale":              {name: "stale"},
+	"Stratum Active":     {name: "stratum-active"},
+	"Stratum Difficulty": {name: "stratum-difficulty"},
+	"Last Share Time":    {name: "last-share-time"},
+	"Pool Rejected%":     {name: "reject-rate"},
+	"Pool Stale%":        {name: "stale-rate"},
+}
+
+var v3EdevKeys = map[string]v3Key{
+	"ASC":             {name: "id"},
+	"Slot":            {name: "slot"},
+	"Status":          {name: "status"},
+	"Temperature":     {name: "temperature"},
+	"Chip Frequency":  {name: "chip-frequency"},
+	"MHS av":          {name: "hash-average", div: 1e6},
+	"HS RT":           {name: "hash-realtime", div: 1e6},
+	"HS Factory":      {name: "factory-hash", div: 1e3},
+	"Upfreq Complete": {name: "upfreq-complete"},
+	"Effective Chips": {name: "effective-chips"},
+	"PCB SN":          {name: "pcb-sn"},
+	"Chip Data":       {name: "chip-data"},
+	"Chip Temp Min":   {name: "chip-temp-min"},
+	"Chip Temp Max":   {name: "chip-temp-max"},
+	"Chip Temp Avg":   {name: "chip-temp-avg"},
+	"chip_vol_diff":   {name: "chip-vol-diff"},
+}
+
+func renameKeys(src map[string]any, keys map[string]v3Key) map[string]any {
+	dst := make(map[string]any, len(keys))
+	for from, k := range keys {
+		v, ok := src[from]
+		if !ok {
+			continue
+		}
+		if n, ok := v.(float64); ok && k.div != 0 {
+			v = n / k.div
+		}
+		dst[k.name] = v
+	}
+	return dst
+}
+
+// v3Writes translate set.* commands into the 2.x write commands that implement them.
+var v3Writes = map[string]func(param any) (string, map[string]any){
+	"set.miner.service": func(param any) (string, map[string]any) {
+		switch param {
+		case "start":
+			return "power_on", nil
+		case "stop":
+			return "power_off", nil
+		case "restart":
+			return "restart_btminer", nil
+		}
+		return "", nil
+	},
+	"set.miner.power_mode": func(param any) (string, map[string]any) {
+		mode, _ := param.(string)
+		return "set_" + mode + "_power", nil
+	},
+	"set.miner.pools": func(param any) (string, map[string]any) {
+		list, _ := param.([]any)
+		params := make(map[string]any)
+		for i, p := range list {
+			pool, _ := p.(map[string]any)
+			params[fmt.Sprintf("pool%d", i+1)] = pool["pool"]
+			params[fmt.Sprintf("worker%d", i+1)] = pool["worker"]
+			params[fmt.Sprintf("passwd%d", i+1)] = pool["passwd"]
+		}
+		return "update_pools", params
+	},
+	"set.system.led": func(param any) (string, map[string]any) {
+		if m, ok := param.(map[string]any); ok {
+			return "set_led", m
+		}
+		return "set_led", map[string]any{"param": param}
+	},
+	"set.system.reboot":        fixedWrite("reboot"),
+	"set.system.factory_reset": fixedWrite("factory_reset"),
+	"set.user.change_passwd": func(param any) (string, map[string]any) {
+		m, _ := param.(map[string]any)
+		return "update_pwd", m
+	},
+	"set.system.net_config": func(param any) (string, map[string]any) {
+		m, _ := param.(map[string]any)
+		if m["proto"] == "dhcp" {
+			return "net_config", map[string]any{"param": "dhcp"}
+		}
+		return "net_config", m
+	},
+	"set.miner.target_freq": valueWrite("set_target_freq", "percent"),
+	"set.miner.fastboot": func(param any) (string, map[string]any) {
+		return fmt.Sprintf("%s_btminer_fast_boot", param), nil
+	},
+	"set.system.hostname":     valueWrite("set_hostname", "hostname"),
+	"set.miner.power_percent": valueWrite("set_power_pct", "percent"),
+	"set.miner.temp_offset":   valueWrite("set_temp_offset", "temp_offset"),
+	"set.miner.power_limit":   valueWrite("adjust_power_limit", "power_limit"),
+	"set.miner.upfreq_speed":  valueWrite("adjust_upfreq_speed", "upfreq_speed"),
+	"set.miner.poweroff_cool": valueWrite("set_poweroff_cool", "poweroff_cool"),
+	"set.fan.zero_speed":      valueWrite("set_fan_zero_speed", "fan_zero_speed"),
+}
+
+func fixedWrite(cmd string) func(any) (string, map[string]any) {
+	return func(any) (string, map[string]any) { return cmd, nil }
+}
+
+func valueWrite(cmd, key string) func(any) (string, map[string]any) {
+	return func(param any) (string, map[string]any) {
+		return cmd, map[string]any{key: param}
+	}
+}